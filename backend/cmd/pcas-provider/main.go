@@ -1,48 +1,196 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/auth"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/health"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
 	"github.com/dreamtrans/backend/internal/pcas"
+	"github.com/dreamtrans/backend/internal/recording"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/speakers"
+	"github.com/dreamtrans/backend/internal/summarize"
+	"github.com/dreamtrans/backend/internal/tlsutil"
+	"github.com/dreamtrans/backend/internal/tracing"
+	"github.com/dreamtrans/backend/internal/webhooks"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("DREAMTRANS_CONFIG"), "path to YAML configuration file")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
-	log.Println("Starting DreamTrans PCAS gRPC Server...")
+	cfg, err := config.NewManager(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := logging.Setup(cfg.Current().Log, os.Stderr); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
+	slog.Info("Starting DreamTrans PCAS gRPC Server")
 
-	// Get port from environment or use default
-	port := os.Getenv("GRPC_PORT")
-	if port == "" {
-		port = "50051"
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Current().Tracing, "dreamtrans-pcas-provider")
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
 	}
+	cfg.WatchSignals(context.Background())
+
+	port := cfg.Current().Provider.GRPCPort
 
 	// Create TCP listener
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		fatal("Failed to listen", err)
+	}
+	slog.Info("Listening", "port", port)
+
+	// Archive session audio and transcripts when recording is enabled
+	var providerOpts []pcas.Option
+	if recCfg := cfg.Current().Recording; recCfg.Enabled {
+		store, err := recording.NewStore(recCfg)
+		if err != nil {
+			fatal("Failed to open recordings", err)
+		}
+		go store.RunRetention(context.Background())
+		providerOpts = append(providerOpts, pcas.WithRecordings(store))
+		slog.Info("Recording enabled", "dir", recCfg.Dir, "format", recCfg.Format, "max_age", recCfg.MaxAge.String())
+	}
+
+	// Store session transcripts when session storage is enabled
+	if sessCfg := cfg.Current().Sessions; sessCfg.Enabled {
+		store, err := sessions.NewStore(sessCfg.Dir)
+		if err != nil {
+			fatal("Failed to open session store", err)
+		}
+		providerOpts = append(providerOpts, pcas.WithSessions(store))
+		slog.Info("Session storage enabled", "dir", sessCfg.Dir)
+	}
+
+	// Identify enrolled speakers when speaker profiles are enabled
+	if spkCfg := cfg.Current().Speakers; spkCfg.Enabled {
+		registry, err := speakers.NewRegistry(spkCfg.File)
+		if err != nil {
+			fatal("Failed to open speaker profiles", err)
+		}
+		providerOpts = append(providerOpts, pcas.WithSpeakerProfiles(registry))
+		slog.Info("Speaker identification enabled", "file", spkCfg.File)
+	}
+
+	// Summarize stored sessions when they end
+	if sumCfg := cfg.Current().Summary; sumCfg.OnEnd {
+		summarizer, err := summarize.New(sumCfg)
+		if err != nil {
+			fatal("Failed to create summarizer", err)
+		}
+		if summarizer != nil {
+			providerOpts = append(providerOpts, pcas.WithSummarizer(summarizer))
+			slog.Info("Session summaries enabled", "summarizer", sumCfg.Summarizer)
+		}
+	}
+
+	// Webhook sinks for transcript lifecycle events
+	dispatcher, err := webhooks.New(cfg.Current().Webhooks)
+	if err != nil {
+		fatal("Failed to create webhook dispatcher", err)
+	}
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		dispatcher.Run(webhookCtx)
+	}()
+	if dispatcher != nil {
+		providerOpts = append(providerOpts, pcas.WithWebhooks(dispatcher))
+		slog.Info("Webhooks enabled", "sinks", len(cfg.Current().Webhooks.Sinks))
+	}
+
+	// Publish transcript events to the PCAS event bus
+	eventsCfg := cfg.Current().Provider.Events
+	eventBus, err := pcas.NewEventBus(eventsCfg)
+	if err != nil {
+		fatal("Failed to create PCAS event publisher", err)
+	}
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	eventsDone := make(chan struct{})
+	go func() {
+		defer close(eventsDone)
+		eventBus.Run(eventsCtx)
+	}()
+	if eventBus != nil {
+		providerOpts = append(providerOpts, pcas.WithEventBus(eventBus))
+		slog.Info("PCAS events enabled", "address", eventsCfg.Address, "segment_type", eventsCfg.SegmentType, "summary_type", eventsCfg.SummaryType)
+	}
+
+	// Alert rules on final transcripts, with optional webhook delivery
+	alertCfg := cfg.Current().Alerts
+	alertEngine, err := alerts.NewEngine(alertCfg.Rules)
+	if err != nil {
+		fatal("Failed to compile alert rules", err)
+	}
+	var alertNotifiers alerts.Notifiers
+	if hook := alerts.NewWebhook(alertCfg.Webhooks, alertCfg.Timeout); hook != nil {
+		go hook.Run(context.Background())
+		alertNotifiers = append(alertNotifiers, hook)
+	}
+	if dispatcher != nil {
+		alertNotifiers = append(alertNotifiers, dispatcher)
+	}
+	if alertEngine != nil {
+		providerOpts = append(providerOpts, pcas.WithAlerts(alertEngine, alertNotifiers))
+		slog.Info("Alerts enabled", "rules", len(alertCfg.Rules), "webhooks", len(alertCfg.Webhooks))
 	}
-	log.Printf("Listening on port %s", port)
 
 	// Create provider instance
-	provider, err := pcas.NewProvider()
+	provider, err := pcas.NewProvider(cfg, providerOpts...)
 	if err != nil {
-		log.Fatalf("Failed to create provider: %v", err)
+		fatal("Failed to create provider", err)
 	}
 
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor),
+	}
+
+	// Enable TLS, and mTLS when a client CA is configured
+	tlsCtx, stopTLS := context.WithCancel(context.Background())
+	defer stopTLS()
+	if tlsCfg := cfg.Current().Provider.TLS; tlsutil.Enabled(tlsCfg) {
+		serverTLS, err := tlsutil.NewServerConfig(tlsCtx, tlsCfg, logging.For("tls"))
+		if err != nil {
+			fatal("Failed to configure TLS", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(serverTLS)))
+		slog.Info("TLS enabled", "cert_file", tlsCfg.CertFile, "mtls", tlsCfg.ClientCAFile != "", "allowed_sans", tlsCfg.AllowedSANs)
+	} else {
+		slog.Warn("TLS disabled, gRPC traffic is unencrypted")
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	// Register the provider service
 	provider.RegisterService(grpcServer)
@@ -50,6 +198,35 @@ func main() {
 	// Register reflection service for easier debugging
 	reflection.Register(grpcServer)
 
+	// Register grpc.health.v1, kept in sync with readiness
+	checker, err := newHealthChecker(cfg, provider)
+	if err != nil {
+		fatal("Failed to initialize health checks", err)
+	}
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	checker.ServeGRPC(healthCtx, healthServer, 10*time.Second, "DreamTransTranscription")
+
+	// Serve Prometheus metrics and HTTP health probes on a separate port
+	metricsPort := cfg.Current().Provider.MetricsPort
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metrics.Handler())
+	metricsMux.HandleFunc("/healthz", checker.LivenessHandler())
+	metricsMux.HandleFunc("/readyz", checker.ReadinessHandler())
+	metricsServer := &http.Server{
+		Addr:              ":" + metricsPort,
+		Handler:           metricsMux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		slog.Info("Serving metrics and health probes", "port", metricsPort)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server error", "error", err)
+		}
+	}()
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -57,7 +234,7 @@ func main() {
 	// Start server in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		log.Println("Starting gRPC server...")
+		slog.Info("Starting gRPC server")
 		if err := grpcServer.Serve(lis); err != nil {
 			errChan <- fmt.Errorf("failed to serve: %v", err)
 		}
@@ -66,13 +243,59 @@ func main() {
 	// Wait for interrupt signal or error
 	select {
 	case sig := <-sigChan:
-		log.Printf("Received signal: %v, shutting down gracefully...", sig)
+		slog.Info("Received signal, shutting down gracefully", "signal", sig.String())
 	case err := <-errChan:
-		log.Printf("Server error: %v", err)
+		slog.Error("Server error", "error", err)
 	}
 
-	// Graceful shutdown
-	log.Println("Stopping gRPC server...")
+	// Graceful shutdown: report not ready first so new streams go elsewhere
+	checker.SetDraining(true)
+	stopHealth()
+	healthServer.Shutdown()
+	slog.Info("Stopping gRPC server")
 	grpcServer.GracefulStop()
-	log.Println("DreamTrans PCAS gRPC Server stopped")
-}
\ No newline at end of file
+	// Undelivered webhook events are kept as dead letters
+	stopWebhooks()
+	<-webhooksDone
+	stopEvents()
+	<-eventsDone
+	if err := eventBus.Close(); err != nil {
+		slog.Warn("Failed to close PCAS connection", "error", err)
+	}
+	if err := metricsServer.Close(); err != nil {
+		slog.Warn("Failed to close metrics server", "error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+	slog.Info("DreamTrans PCAS gRPC Server stopped")
+}
+
+// newHealthChecker registers the readiness checks for the provider
+func newHealthChecker(cfg *config.Manager, provider *pcas.Provider) (*health.Checker, error) {
+	tokenGen, err := auth.NewTokenGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := health.NewChecker()
+	checker.Add("config", 0, func(context.Context) (string, error) {
+		return "", cfg.Current().Validate()
+	})
+	checker.Add("token", 5*time.Minute, func(ctx context.Context) (string, error) {
+		_, err := tokenGen.GenerateToken(ctx)
+		return "", err
+	})
+	checker.Add("sessions", 0, health.SessionLoad(provider.ActiveSessions, func() int {
+		return cfg.Current().Transcription.MaxSessions
+	}))
+	return checker, nil
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
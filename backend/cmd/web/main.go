@@ -1,55 +1,286 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/auth"
+	"github.com/dreamtrans/backend/internal/config"
 	"github.com/dreamtrans/backend/internal/handlers"
+	"github.com/dreamtrans/backend/internal/health"
+	"github.com/dreamtrans/backend/internal/live"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/origin"
+	"github.com/dreamtrans/backend/internal/recording"
+	"github.com/dreamtrans/backend/internal/search"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/speakers"
+	"github.com/dreamtrans/backend/internal/speechmatics"
+	"github.com/dreamtrans/backend/internal/summarize"
+	"github.com/dreamtrans/backend/internal/tlsutil"
+	"github.com/dreamtrans/backend/internal/tracing"
+	"github.com/dreamtrans/backend/internal/translate"
+	"github.com/dreamtrans/backend/internal/webhooks"
 	"github.com/joho/godotenv"
-	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("DREAMTRANS_CONFIG"), "path to YAML configuration file")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
-	tokenHandler, err := handlers.NewTokenHandler()
+	cfg, err := config.NewManager(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := logging.Setup(cfg.Current().Log, os.Stderr); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
+	cfg.WatchSignals(context.Background())
+	webCfg := cfg.Current().Web
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Current().Tracing, "dreamtrans-web")
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+
+	tokenHandler, err := handlers.NewTokenHandler(cfg)
+	if err != nil {
+		fatal("Failed to initialize token handler", err)
+	}
+
+	// Session store, shared with the PCAS provider; nil when disabled
+	var sessionStore *sessions.Store
+	if sessCfg := cfg.Current().Sessions; sessCfg.Enabled {
+		sessionStore, err = sessions.NewStore(sessCfg.Dir)
+		if err != nil {
+			fatal("Failed to open session store", err)
+		}
+	}
+
+	// Enrolled speaker profiles, shared with the PCAS provider; nil when disabled
+	var speakerRegistry *speakers.Registry
+	if spkCfg := cfg.Current().Speakers; spkCfg.Enabled {
+		speakerRegistry, err = speakers.NewRegistry(spkCfg.File)
+		if err != nil {
+			fatal("Failed to open speaker profiles", err)
+		}
+	}
+
+	// Machine translation backend; nil when disabled or done by Speechmatics
+	translator, err := translate.New(cfg.Current().Translation)
+	if err != nil {
+		fatal("Failed to create translation backend", err)
+	}
+
+	// Meeting summarizer for stored sessions; nil when disabled
+	summarizer, err := summarize.New(cfg.Current().Summary)
+	if err != nil {
+		fatal("Failed to create summarizer", err)
+	}
+
+	// Webhook sinks for transcript lifecycle events; nil when none are configured
+	dispatcher, err := webhooks.New(cfg.Current().Webhooks)
 	if err != nil {
-		log.Fatalf("Failed to initialize token handler: %v", err)
+		fatal("Failed to create webhook dispatcher", err)
 	}
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		dispatcher.Run(webhookCtx)
+	}()
 
-	batchHandler, err := handlers.NewBatchTranscribeHandler()
+	var batchOpts []handlers.BatchOption
+	if sessionStore != nil {
+		batchOpts = append(batchOpts, handlers.WithBatchSessions(sessionStore))
+	}
+	if speakerRegistry != nil {
+		batchOpts = append(batchOpts, handlers.WithBatchSpeakerProfiles(speakerRegistry))
+	}
+	if translator != nil {
+		batchOpts = append(batchOpts, handlers.WithBatchTranslator(translator))
+	}
+	if summarizer != nil {
+		batchOpts = append(batchOpts, handlers.WithBatchSummarizer(summarizer))
+	}
+	if dispatcher != nil {
+		batchOpts = append(batchOpts, handlers.WithBatchWebhooks(dispatcher))
+	}
+	batchHandler, err := handlers.NewBatchTranscribeHandler(cfg, batchOpts...)
+	if err != nil {
+		fatal("Failed to initialize batch transcribe handler", err)
+	}
+
+	// One origin policy for both CORS and WebSocket upgrades
+	policy, err := origin.NewPolicy(webCfg.AllowedOrigins)
+	if err != nil {
+		fatal("Invalid allowed origins", err)
+	}
+
+	wsHandler := handlers.NewWebSocketHandler(policy)
+
+	checker, err := newHealthChecker(cfg, wsHandler)
 	if err != nil {
-		log.Fatalf("Failed to initialize batch transcribe handler: %v", err)
+		fatal("Failed to initialize health checks", err)
 	}
 
 	// Create a new mux to handle routes
 	mux := http.NewServeMux()
 
+	// Liveness and readiness probes
+	mux.HandleFunc("/healthz", checker.LivenessHandler())
+	mux.HandleFunc("/readyz", checker.ReadinessHandler())
+
+	// handle registers a handler wrapped in a tracing span named after its route
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, otelhttp.NewHandler(h, pattern))
+	}
+
 	// API and WebSocket handlers
-	mux.HandleFunc("/api/token/rt", tokenHandler.HandleTokenRequest)
-	mux.HandleFunc("/ws/translate", handlers.HandleWebSocket)
+	handle("/api/token/rt", tokenHandler.HandleTokenRequest)
+	handle("/ws/translate", wsHandler.HandleWebSocket)
 
 	// Batch transcription endpoints
-	mux.HandleFunc("/api/transcribe/batch/submit", batchHandler.HandleSubmit)
-	mux.HandleFunc("/api/transcribe/batch/status", batchHandler.HandleStatus)
-	mux.HandleFunc("/api/transcribe/batch", batchHandler.HandleTranscribeAndWait)
+	handle("/api/transcribe/batch/submit", batchHandler.HandleSubmit)
+	handle("/api/transcribe/batch/status", batchHandler.HandleStatus)
+	handle("/api/transcribe/batch", batchHandler.HandleTranscribeAndWait)
+	handle("GET /api/transcribe/batch/jobs/{id}/events", batchHandler.HandleJobEvents)
+
+	// Recordings archived by the PCAS provider
+	if recCfg := cfg.Current().Recording; recCfg.Enabled {
+		store, err := recording.NewStore(recCfg)
+		if err != nil {
+			fatal("Failed to open recordings", err)
+		}
+		recordingsHandler := handlers.NewRecordingsHandler(store)
+		handle("GET /api/recordings", recordingsHandler.HandleList)
+		handle("GET /api/recordings/{id}", recordingsHandler.HandleGet)
+		handle("GET /api/recordings/{id}/audio", recordingsHandler.HandleDownload)
+		handle("DELETE /api/recordings/{id}", recordingsHandler.HandleDelete)
+	}
+
+	// Stored sessions, written by the PCAS provider, batch jobs or imported from the browser
+	if sessionStore != nil {
+		sessionsHandler := handlers.NewSessionsHandler(sessionStore, summarizer)
+		handle("GET /api/sessions", sessionsHandler.HandleList)
+		handle("POST /api/sessions", sessionsHandler.HandleCreate)
+		handle("GET /api/sessions/{id}", sessionsHandler.HandleGet)
+		handle("PATCH /api/sessions/{id}", sessionsHandler.HandleUpdate)
+		handle("DELETE /api/sessions/{id}", sessionsHandler.HandleDelete)
+		handle("GET /api/sessions/{id}/export", sessionsHandler.HandleExport)
+		handle("PATCH /api/sessions/{id}/segments/{segment}", sessionsHandler.HandleEditSegment)
+		handle("POST /api/sessions/{id}/segments/{segment}/split", sessionsHandler.HandleSplitSegment)
+		handle("POST /api/sessions/{id}/segments/merge", sessionsHandler.HandleMergeSegments)
+		handle("GET /api/sessions/{id}/revisions", sessionsHandler.HandleRevisions)
+		handle("POST /api/sessions/{id}/revisions/{rev}/revert", sessionsHandler.HandleRevert)
+		handle("GET /api/sessions/{id}/diff", sessionsHandler.HandleDiff)
+		handle("GET /api/sessions/{id}/summary", sessionsHandler.HandleSummary)
+		handle("POST /api/sessions/{id}/summary", sessionsHandler.HandleSummarize)
+
+		index, err := search.NewIndex(sessionStore)
+		if err != nil {
+			fatal("Failed to build search index", err)
+		}
+		handle("GET /api/search", handlers.NewSearchHandler(index).HandleSearch)
+	}
+
+	// Alert rules on final transcripts, with optional webhook delivery
+	alertCfg := cfg.Current().Alerts
+	alertEngine, err := alerts.NewEngine(alertCfg.Rules)
+	if err != nil {
+		fatal("Failed to compile alert rules", err)
+	}
+	var alertNotifiers alerts.Notifiers
+	if hook := alerts.NewWebhook(alertCfg.Webhooks, alertCfg.Timeout); hook != nil {
+		go hook.Run(context.Background())
+		alertNotifiers = append(alertNotifiers, hook)
+	}
+	if dispatcher != nil {
+		alertNotifiers = append(alertNotifiers, dispatcher)
+	}
+
+	// Live broadcasts: one producer, many subscribers joining by code
+	var hubOpts []live.Option
+	if alertEngine != nil {
+		hubOpts = append(hubOpts, live.WithAlerts(alertEngine, alertNotifiers))
+	}
+	if translator != nil {
+		hubOpts = append(hubOpts, live.WithTranslator(translator, cfg.Current().Translation))
+	}
+	if summarizer != nil && cfg.Current().Summary.OnEnd {
+		hubOpts = append(hubOpts, live.WithSummarizer(summarizer, cfg.Current().Summary))
+	}
+	if dispatcher != nil {
+		hubOpts = append(hubOpts, live.WithWebhooks(dispatcher))
+	}
+	hub := live.NewHub(cfg.Current().Live, sessionStore, hubOpts...)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	go hub.Run(hubCtx)
+	liveHandler := handlers.NewLiveHandler(hub, wsHandler)
+	handle("POST /api/live", liveHandler.HandleCreate)
+	handle("GET /api/live/{code}", liveHandler.HandleGet)
+	handle("GET /api/live/{code}/events", liveHandler.HandleEvents)
+	handle("GET /ws/live/{code}", liveHandler.HandleSubscribe)
+	handle("GET /ws/live/{code}/publish", liveHandler.HandlePublish)
+	if sessionStore != nil {
+		handle("GET /api/sessions/{id}/events", liveHandler.HandleSessionEvents)
+	}
+
+	// Speaker profiles for speaker identification
+	if speakerRegistry != nil {
+		speakersHandler := handlers.NewSpeakersHandler(speakerRegistry, sessionStore)
+		handle("GET /api/speakers", speakersHandler.HandleList)
+		handle("POST /api/speakers", speakersHandler.HandleEnroll)
+		handle("DELETE /api/speakers/{id}", speakersHandler.HandleDelete)
+	}
+
+	// Webhook delivery log and dead letter queue
+	if dispatcher != nil {
+		webhooksHandler := handlers.NewWebhooksHandler(dispatcher)
+		handle("GET /api/webhooks/deliveries", webhooksHandler.HandleDeliveries)
+		handle("GET /api/webhooks/dead-letters", webhooksHandler.HandleDeadLetters)
+		handle("POST /api/webhooks/dead-letters/{id}/redeliver", webhooksHandler.HandleRedeliver)
+		handle("DELETE /api/webhooks/dead-letters/{id}", webhooksHandler.HandleDeleteDeadLetter)
+	}
+
+	// Prometheus metrics
+	mux.Handle("/metrics", metrics.Handler())
 
 	// Static file server for SPA
-	publicDir := "./public"
+	publicDir := webCfg.PublicDir
 
 	// Check if public directory exists, if not, create it
 	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		log.Printf("Public directory does not exist, creating %s", publicDir)
+		slog.Info("Public directory does not exist, creating it", "dir", publicDir)
 		if err := os.MkdirAll(publicDir, 0o755); err != nil {
-			log.Fatalf("Failed to create public directory: %v", err)
+			fatal("Failed to create public directory", err)
 		}
 	}
 
@@ -87,41 +318,144 @@ func main() {
 		fs.ServeHTTP(w, r)
 	})
 
-	// Setup CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"}, // Allow all origins for development
-		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization"},
-	})
-
 	// Apply CORS middleware
-	handler := c.Handler(mux)
-
-	// Get port from environment variable or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	handler := logging.Middleware(policy.CORS().Handler(mux))
 
+	port := webCfg.Port
 	addr := ":" + port
-	fmt.Printf("Server starting on port %s\n", port)
-	fmt.Printf("- API endpoint: http://localhost:%s/api/token/rt\n", port)
-	fmt.Printf("- WebSocket endpoint: ws://localhost:%s/ws/translate\n", port)
-	fmt.Printf("- Batch transcription: http://localhost:%s/api/transcribe/batch\n", port)
-	fmt.Printf("- Static files served from: %s\n", publicDir)
-	fmt.Println("- CORS enabled for all origins")
+	slog.Info("Server starting",
+		"port", port,
+		"token_endpoint", "/api/token/rt",
+		"websocket_endpoint", "/ws/translate",
+		"live_endpoints", "/api/live, /ws/live/{code}",
+		"batch_endpoint", "/api/transcribe/batch",
+		"metrics_endpoint", "/metrics",
+		"health_endpoints", "/healthz, /readyz",
+		"public_dir", publicDir,
+		"allowed_origins", webCfg.AllowedOrigins)
 
 	// Create server with timeouts (increased for batch processing)
 	srv := &http.Server{
 		Addr:         addr,
 		Handler:      handler,
-		ReadTimeout:  5 * time.Minute,  // Increased for file uploads
-		WriteTimeout: 15 * time.Minute, // Increased for batch processing
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  webCfg.ReadTimeout,
+		WriteTimeout: webCfg.WriteTimeout,
+		IdleTimeout:  webCfg.IdleTimeout,
 	}
+	// Event streams never finish on their own; end them when draining
+	srv.RegisterOnShutdown(hub.Shutdown)
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	tlsCtx, stopTLS := context.WithCancel(context.Background())
+	defer stopTLS()
+	useTLS := tlsutil.Enabled(webCfg.TLS)
+	if useTLS {
+		srv.TLSConfig, err = tlsutil.NewServerConfig(tlsCtx, webCfg.TLS, logging.For("tls"))
+		if err != nil {
+			fatal("Failed to configure TLS", err)
+		}
+		slog.Info("TLS enabled", "cert_file", webCfg.TLS.CertFile, "client_auth", webCfg.TLS.ClientCAFile != "")
 	}
+
+	// Handle graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	errChan := make(chan error, 1)
+	go func() {
+		var err error
+		if useTLS {
+			// Certificates come from TLSConfig so that they can be reloaded
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case sig := <-sigChan:
+		slog.Info("Received signal, draining", "signal", sig.String(), "drain_timeout", webCfg.DrainTimeout.String())
+	case err := <-errChan:
+		slog.Error("Server error", "error", err)
+	}
+
+	// Drain with the settings in effect now, which may have been reloaded
+	drainCfg := cfg.Current().Web
+	shutdown(srv, wsHandler, hub, checker, drainCfg.ReadinessDrainDelay, drainCfg.DrainTimeout)
+	// Undelivered webhook events are kept as dead letters
+	stopWebhooks()
+	<-webhooksDone
+	slog.Info("DreamTrans web server stopped")
+}
+
+// shutdown flips readiness to draining and keeps serving for readinessDelay,
+// so that load balancers see it, then stops accepting connections and
+// waits up to drainTimeout for in-flight requests and WebSocket sessions.
+// WebSocket clients receive a close frame asking them to reconnect. Finally
+// the finals of running live broadcasts are flushed to the session store;
+// the server only starts hub.Shutdown in the background, so it is awaited
+// here before we exit.
+func shutdown(srv *http.Server, wsHandler *handlers.WebSocketHandler, hub *live.Hub, checker *health.Checker, readinessDelay, drainTimeout time.Duration) {
+	checker.SetDraining(true)
+	if readinessDelay > 0 {
+		slog.Info("Readiness reports draining, still serving", "readiness_drain_delay", readinessDelay.String())
+		time.Sleep(readinessDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := wsHandler.Drain(ctx); err != nil {
+			slog.Warn("WebSocket sessions did not finish within the drain window", "error", err)
+		}
+	}()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("In-flight requests did not finish within the drain window", "error", err)
+		if err := srv.Close(); err != nil {
+			slog.Warn("Failed to close server", "error", err)
+		}
+	}
+	wg.Wait()
+	hub.Shutdown()
+}
+
+// newHealthChecker registers the readiness checks for the web server
+func newHealthChecker(cfg *config.Manager, wsHandler *handlers.WebSocketHandler) (*health.Checker, error) {
+	tokenGen, err := auth.NewTokenGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	batchClient, err := speechmatics.NewBatchClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := health.NewChecker()
+	checker.Add("config", 0, func(context.Context) (string, error) {
+		return "", cfg.Current().Validate()
+	})
+	checker.Add("token", 5*time.Minute, func(ctx context.Context) (string, error) {
+		_, err := tokenGen.GenerateToken(ctx)
+		return "", err
+	})
+	checker.Add("jobs", time.Minute, func(ctx context.Context) (string, error) {
+		return "", batchClient.Ping(ctx)
+	})
+	checker.Add("sessions", 0, health.SessionLoad(wsHandler.ActiveSessions, func() int {
+		return cfg.Current().Transcription.MaxSessions
+	}))
+	return checker, nil
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/handlers"
+	"github.com/dreamtrans/backend/internal/health"
+	"github.com/dreamtrans/backend/internal/live"
+	"github.com/dreamtrans/backend/internal/origin"
+)
+
+func TestShutdownReportsDrainingBeforeClosing(t *testing.T) {
+	checker := health.NewChecker()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", checker.ReadinessHandler())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	url := "http://" + ln.Addr().String() + "/readyz"
+
+	policy, err := origin.NewPolicy(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsHandler := handlers.NewWebSocketHandler(policy)
+	hub := live.NewHub(config.Default().Live, nil)
+
+	readyz := func() (int, error) {
+		resp, err := http.Get(url)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	if code, err := readyz(); err != nil || code != http.StatusOK {
+		t.Fatalf("readyz before shutdown = %d, %v, want 200", code, err)
+	}
+
+	const delay = 300 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shutdown(srv, wsHandler, hub, checker, delay, time.Second)
+	}()
+
+	// Load balancers polling during the delay see the draining state
+	time.Sleep(delay / 3)
+	if code, err := readyz(); err != nil || code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining = %d, %v, want 503", code, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not return")
+	}
+	if _, err := readyz(); err == nil {
+		t.Error("server still accepts connections after shutdown")
+	}
+}
@@ -0,0 +1,201 @@
+// Package alerts raises alerts when configured phrases are said in realtime
+// transcripts
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/metrics"
+)
+
+// Match types of alert rules
+const (
+	MatchKeyword = "keyword"
+	MatchRegex   = "regex"
+	MatchFuzzy   = "fuzzy"
+)
+
+// Alert is raised when a final transcript matches a rule
+type Alert struct {
+	Rule string `json:"rule"`
+	// Pattern is the rule pattern that matched and Match the text that
+	// matched it
+	Pattern      string    `json:"pattern"`
+	Match        string    `json:"match"`
+	Text         string    `json:"text"`
+	Speaker      string    `json:"speaker,omitempty"`
+	SpeakerLabel string    `json:"speaker_label,omitempty"`
+	StartTime    float64   `json:"start_time"`
+	EndTime      float64   `json:"end_time"`
+	SessionID    string    `json:"session_id,omitempty"`
+	LiveCode     string    `json:"live_code,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Notifier delivers alerts outside the session they were raised in
+type Notifier interface {
+	Notify(ctx context.Context, a Alert)
+}
+
+// Notifiers passes alerts to each of its notifiers
+type Notifiers []Notifier
+
+// Notify implements Notifier
+func (ns Notifiers) Notify(ctx context.Context, a Alert) {
+	for _, n := range ns {
+		n.Notify(ctx, a)
+	}
+}
+
+// Segment is a final transcript segment checked against the rules
+type Segment struct {
+	Speaker      string
+	SpeakerLabel string
+	Text         string
+	StartTime    float64
+	EndTime      float64
+}
+
+// rule is a compiled alert rule
+type rule struct {
+	config.AlertRule
+	regexps  []*regexp.Regexp
+	keywords [][]string
+	speakers map[string]bool
+	// maxDistance is the fuzzy edit limit, or -1 to scale it with the pattern
+	maxDistance int
+}
+
+// Engine holds the compiled rules. It is shared by all sessions; each
+// session checks its transcript with its own Matcher.
+type Engine struct {
+	rules []*rule
+}
+
+// NewEngine compiles the rules. It returns nil when there are none.
+func NewEngine(rules []config.AlertRule) (*Engine, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	e := &Engine{}
+	for _, cfg := range rules {
+		r := &rule{AlertRule: cfg, maxDistance: -1}
+		if cfg.MaxDistance != nil {
+			r.maxDistance = *cfg.MaxDistance
+		}
+		if r.Match == "" {
+			r.Match = MatchKeyword
+		}
+		for _, p := range cfg.Patterns {
+			switch r.Match {
+			case MatchRegex:
+				re, err := regexp.Compile(p)
+				if err != nil {
+					return nil, fmt.Errorf("alert rule %q: %w", cfg.Name, err)
+				}
+				r.regexps = append(r.regexps, re)
+			case MatchKeyword, MatchFuzzy:
+				r.keywords = append(r.keywords, words(p))
+			default:
+				return nil, fmt.Errorf("alert rule %q: unknown match %q", cfg.Name, cfg.Match)
+			}
+		}
+		if len(cfg.Speakers) > 0 {
+			r.speakers = make(map[string]bool, len(cfg.Speakers))
+			for _, sp := range cfg.Speakers {
+				r.speakers[strings.ToLower(sp)] = true
+			}
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+// Matcher checks the transcript of one session against the rules and keeps
+// the cooldowns of that session. A nil Matcher matches nothing.
+type Matcher struct {
+	rules []*rule
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// Matcher creates a matcher for a new session, or nil for a nil Engine
+func (e *Engine) Matcher() *Matcher {
+	if e == nil {
+		return nil
+	}
+	return &Matcher{rules: e.rules, last: make(map[string]time.Time)}
+}
+
+// Match returns an alert for each rule that matches the segment and is not
+// cooling down. A rule raises at most one alert per segment.
+func (m *Matcher) Match(seg Segment) []Alert {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var alerts []Alert
+	for _, r := range m.rules {
+		if r.speakers != nil && !r.speakers[strings.ToLower(seg.SpeakerLabel)] && !r.speakers[strings.ToLower(seg.Speaker)] {
+			continue
+		}
+		if last, ok := m.last[r.Name]; ok && now.Sub(last) < r.Cooldown {
+			continue
+		}
+		pattern, match, ok := r.find(seg.Text)
+		if !ok {
+			continue
+		}
+		m.last[r.Name] = now
+		metrics.Alerts.WithLabelValues(r.Name).Inc()
+		alerts = append(alerts, Alert{
+			Rule:         r.Name,
+			Pattern:      pattern,
+			Match:        match,
+			Text:         seg.Text,
+			Speaker:      seg.Speaker,
+			SpeakerLabel: seg.SpeakerLabel,
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Time:         now,
+		})
+	}
+	return alerts
+}
+
+// find returns the first pattern of the rule found in text and the text
+// that matched it
+func (r *rule) find(text string) (pattern, match string, ok bool) {
+	if r.Match == MatchRegex {
+		for i, re := range r.regexps {
+			if loc := re.FindStringIndex(text); loc != nil {
+				return r.Patterns[i], text[loc[0]:loc[1]], true
+			}
+		}
+		return "", "", false
+	}
+
+	tokens := tokenize(text)
+	for i, keyword := range r.keywords {
+		var start, end int
+		if r.Match == MatchFuzzy {
+			start, end, ok = findFuzzy(tokens, keyword, r.maxDistance)
+		} else {
+			start, end, ok = findKeyword(tokens, keyword)
+		}
+		if ok {
+			return r.Patterns[i], text[start:end], true
+		}
+	}
+	return "", "", false
+}
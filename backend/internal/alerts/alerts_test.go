@@ -0,0 +1,147 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestMatchTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  config.AlertRule
+		text  string
+		match string
+	}{
+		{"keyword", config.AlertRule{Patterns: []string{"outage"}}, "We have an Outage now.", "Outage"},
+		{"keyword phrase", config.AlertRule{Patterns: []string{"service down"}}, "is the service down?", "service down"},
+		{"keyword whole words", config.AlertRule{Patterns: []string{"out"}}, "the outage", ""},
+		{"keyword apostrophe", config.AlertRule{Patterns: []string{"can't"}}, "I can't log in", "can't"},
+		{"keyword CJK", config.AlertRule{Patterns: []string{"障害"}}, "システム障害が発生しました", "障害"},
+		{"keyword CJK in latin text", config.AlertRule{Patterns: []string{"返金"}}, "please 返金して", "返金"},
+		{"regex", config.AlertRule{Match: MatchRegex, Patterns: []string{`\border #?\d+`}}, "about order #123 today", "order #123"},
+		{"regex no match", config.AlertRule{Match: MatchRegex, Patterns: []string{`^refund$`}}, "a refund", ""},
+		{"fuzzy short pattern", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"help"}}, "halp me", ""},
+		{"fuzzy typo", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"refund"}}, "I want a refnd", "refnd"},
+		{"fuzzy long typo", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"cancellation"}}, "the cancelation fee", "cancelation"},
+		{"fuzzy split word", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"refund"}, MaxDistance: intPtr(1)}, "a re fund please", "re fund"},
+		{"fuzzy explicit distance", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"refund"}, MaxDistance: intPtr(1)}, "I want a refnd", "refnd"},
+		{"fuzzy exact", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"cancellation"}, MaxDistance: intPtr(0)}, "the cancelation fee", ""},
+		{"fuzzy exact match", config.AlertRule{Match: MatchFuzzy, Patterns: []string{"refund"}, MaxDistance: intPtr(0)}, "a Refund", "Refund"},
+		{"second pattern", config.AlertRule{Patterns: []string{"outage", "incident"}}, "an incident", "incident"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = tt.name
+			e, err := NewEngine([]config.AlertRule{tt.rule})
+			if err != nil {
+				t.Fatalf("NewEngine error: %v", err)
+			}
+			alerts := e.Matcher().Match(Segment{Text: tt.text})
+			switch {
+			case tt.match == "" && len(alerts) > 0:
+				t.Errorf("%q matched %q", tt.text, alerts[0].Match)
+			case tt.match != "" && len(alerts) != 1:
+				t.Errorf("%q raised %d alerts, want 1", tt.text, len(alerts))
+			case tt.match != "" && alerts[0].Match != tt.match:
+				t.Errorf("%q matched %q, want %q", tt.text, alerts[0].Match, tt.match)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text  string
+		words []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"it's 3pm", []string{"it's", "3pm"}},
+		{"東京タワー", []string{"東", "京", "タ", "ワ", "ー"}},
+		{"go to 東京 now", []string{"go", "to", "東", "京", "now"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := words(tt.text)
+			if len(got) != len(tt.words) {
+				t.Fatalf("words = %q, want %q", got, tt.words)
+			}
+			for i := range got {
+				if got[i] != tt.words[i] {
+					t.Errorf("words = %q, want %q", got, tt.words)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSpeakerFilter(t *testing.T) {
+	e, err := NewEngine([]config.AlertRule{{Name: "boss", Patterns: []string{"deadline"}, Speakers: []string{"Alice", "s2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		speaker, label string
+		want           bool
+	}{
+		{"Alice", "S1", true},
+		{"alice", "", true},
+		{"Bob", "S2", true},
+		{"Bob", "S3", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		m := e.Matcher()
+		got := len(m.Match(Segment{Speaker: tt.speaker, SpeakerLabel: tt.label, Text: "the deadline"})) > 0
+		if got != tt.want {
+			t.Errorf("speaker %q label %q: alert = %v, want %v", tt.speaker, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	e, err := NewEngine([]config.AlertRule{
+		{Name: "slow", Patterns: []string{"refund"}, Cooldown: time.Hour},
+		{Name: "always", Patterns: []string{"refund"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := e.Matcher()
+	seg := Segment{Text: "refund"}
+	if got := m.Match(seg); len(got) != 2 {
+		t.Fatalf("first segment raised %d alerts, want 2", len(got))
+	}
+	got := m.Match(seg)
+	if len(got) != 1 || got[0].Rule != "always" {
+		t.Errorf("second segment raised %+v, want only the rule without cooldown", got)
+	}
+	// Cooldowns are kept per session
+	if got := e.Matcher().Match(seg); len(got) != 2 {
+		t.Errorf("new session raised %d alerts, want 2", len(got))
+	}
+
+	// A rule that does not match does not start its cooldown
+	m = e.Matcher()
+	m.Match(Segment{Text: "nothing"})
+	if got := m.Match(seg); len(got) != 2 {
+		t.Errorf("raised %d alerts after an unmatched segment, want 2", len(got))
+	}
+}
+
+func TestNilEngine(t *testing.T) {
+	e, err := NewEngine(nil)
+	if err != nil || e != nil {
+		t.Fatalf("NewEngine(nil) = %v, %v", e, err)
+	}
+	if got := e.Matcher().Match(Segment{Text: "anything"}); got != nil {
+		t.Errorf("nil matcher raised %+v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package alerts
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a lower-cased word of a transcript with its byte offsets
+type token struct {
+	word       string
+	start, end int
+}
+
+// tokenize splits text into words. Characters of scripts written without
+// spaces, such as Chinese and Japanese, are words of their own, so that
+// keywords in those scripts match anywhere.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{word: strings.ToLower(text[start:end]), start: start, end: end})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flush(i)
+			end := i + utf8.RuneLen(r)
+			tokens = append(tokens, token{word: string(r), start: i, end: end})
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '\'':
+			if start < 0 {
+				start = i
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+// words returns the lower-cased words of a pattern
+func words(pattern string) []string {
+	tokens := tokenize(pattern)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.word
+	}
+	return words
+}
+
+// findKeyword returns the byte range of the first run of tokens equal to
+// the keyword
+func findKeyword(tokens []token, keyword []string) (int, int, bool) {
+	n := len(keyword)
+	if n == 0 {
+		return 0, 0, false
+	}
+next:
+	for i := 0; i+n <= len(tokens); i++ {
+		for j, w := range keyword {
+			if tokens[i+j].word != w {
+				continue next
+			}
+		}
+		return tokens[i].start, tokens[i+n-1].end, true
+	}
+	return 0, 0, false
+}
+
+// findFuzzy returns the byte range of the run of tokens closest to the
+// keyword, if it is within maxDistance edits. Runs of one word more or less
+// are compared too, so that "re fund" matches "refund". A negative
+// maxDistance allows one edit per five characters.
+func findFuzzy(tokens []token, keyword []string, maxDistance int) (int, int, bool) {
+	n := len(keyword)
+	if n == 0 {
+		return 0, 0, false
+	}
+	want := strings.Join(keyword, " ")
+	if maxDistance < 0 {
+		maxDistance = utf8.RuneCountInString(want) / 5
+	}
+
+	best, start, end := maxDistance+1, 0, 0
+	for i := range tokens {
+		for size := max(n-1, 1); size <= n+1 && i+size <= len(tokens); size++ {
+			words := make([]string, size)
+			for j := range words {
+				words[j] = tokens[i+j].word
+			}
+			if d := distance(strings.Join(words, " "), want); d < best {
+				best, start, end = d, tokens[i].start, tokens[i+size-1].end
+			}
+		}
+	}
+	return start, end, best <= maxDistance
+}
+
+// distance returns the Levenshtein distance between a and b in runes
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// isCJK reports whether r belongs to a script written without spaces
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// webhookQueue is the number of alerts waiting for delivery before new
+// alerts are dropped
+const webhookQueue = 256
+
+// Webhook posts alerts as JSON to a list of URLs. Alerts are queued by
+// Notify and delivered in the background by Run, so a slow endpoint never
+// holds up a session.
+type Webhook struct {
+	urls   []string
+	client *http.Client
+	queue  chan Alert
+	logger *slog.Logger
+}
+
+// NewWebhook creates a notifier for urls. timeout bounds each delivery. It
+// returns nil when there are no urls.
+func NewWebhook(urls []string, timeout time.Duration) *Webhook {
+	if len(urls) == 0 {
+		return nil
+	}
+	return &Webhook{
+		urls: urls,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		queue:  make(chan Alert, webhookQueue),
+		logger: logging.For("alerts"),
+	}
+}
+
+// Notify queues an alert for delivery. Alerts are dropped when the queue is
+// full. A nil Webhook drops every alert.
+func (w *Webhook) Notify(ctx context.Context, a Alert) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.queue <- a:
+	default:
+		metrics.AlertDeliveries.WithLabelValues("dropped").Inc()
+		w.logger.WarnContext(ctx, "Alert webhook queue full, dropping alert", "rule", a.Rule)
+	}
+}
+
+// Run delivers queued alerts until ctx is done
+func (w *Webhook) Run(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	for {
+		select {
+		case a := <-w.queue:
+			for _, url := range w.urls {
+				err := w.post(ctx, url, a)
+				metrics.AlertDeliveries.WithLabelValues(metrics.Outcome(err)).Inc()
+				if err != nil {
+					w.logger.ErrorContext(ctx, "Failed to deliver alert", "rule", a.Rule, "url", url, "error", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// post sends one alert to url
+func (w *Webhook) post(ctx context.Context, url string, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -2,30 +2,48 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/tracing"
 )
 
 type TokenGenerator struct {
-	apiKey string
+	apiKey     string
+	ttl        time.Duration
+	httpClient *http.Client
 }
 
-func NewTokenGenerator() (*TokenGenerator, error) {
-	apiKey := os.Getenv("SM_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("SM_API_KEY environment variable not set")
+func NewTokenGenerator(cfg *config.Manager) (*TokenGenerator, error) {
+	sm := cfg.Current().Speechmatics
+	if sm.APIKey == "" {
+		return nil, fmt.Errorf("speechmatics API key not configured")
 	}
-	return &TokenGenerator{apiKey: apiKey}, nil
+	return &TokenGenerator{
+		apiKey:     sm.APIKey,
+		ttl:        sm.TokenTTL,
+		httpClient: &http.Client{Timeout: sm.RequestTimeout},
+	}, nil
 }
 
 // GenerateToken calls Speechmatics API to get a temporary key
-func (tg *TokenGenerator) GenerateToken() (string, error) {
-	// Create request body for RT temporary key with 10 minute TTL
+func (tg *TokenGenerator) GenerateToken(ctx context.Context) (token string, err error) {
+	ctx, span := tracing.Tracer("auth").Start(ctx, "auth.GenerateToken")
+	start := time.Now()
+	defer func() {
+		metrics.TokenLatency.WithLabelValues(metrics.Outcome(err)).Observe(metrics.Since(start))
+		tracing.End(span, err)
+	}()
+
+	// Create request body for RT temporary key with the configured TTL
 	requestBody := map[string]interface{}{
-		"ttl": 600, // 10 minutes in seconds
+		"ttl": int(tg.ttl.Seconds()),
 	}
 
 	jsonBody, err := json.Marshal(requestBody)
@@ -34,7 +52,7 @@ func (tg *TokenGenerator) GenerateToken() (string, error) {
 	}
 
 	// Create request to Speechmatics temporary key endpoint
-	req, err := http.NewRequest("POST", "https://mp.speechmatics.com/v1/api_keys?type=rt", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://mp.speechmatics.com/v1/api_keys?type=rt", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -44,8 +62,7 @@ func (tg *TokenGenerator) GenerateToken() (string, error) {
 	req.Header.Set("Authorization", "Bearer "+tg.apiKey)
 
 	// Make request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := tg.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to make request: %w", err)
 	}
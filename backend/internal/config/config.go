@@ -0,0 +1,847 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds all settings for the DreamTrans binaries
+type Config struct {
+	Speechmatics  SpeechmaticsConfig  `yaml:"speechmatics"`
+	Transcription TranscriptionConfig `yaml:"transcription"`
+	Batch         BatchConfig         `yaml:"batch"`
+	Web           WebConfig           `yaml:"web"`
+	Provider      ProviderConfig      `yaml:"provider"`
+	Log           logging.Config      `yaml:"log"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+	Recording     RecordingConfig     `yaml:"recording"`
+	Sessions      SessionsConfig      `yaml:"sessions"`
+	Speakers      SpeakersConfig      `yaml:"speakers"`
+	Live          LiveConfig          `yaml:"live"`
+	Translation   TranslationConfig   `yaml:"translation"`
+	Summary       SummaryConfig       `yaml:"summary"`
+	Alerts        AlertsConfig        `yaml:"alerts"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+}
+
+// SpeechmaticsConfig contains credentials and endpoints for the Speechmatics APIs
+type SpeechmaticsConfig struct {
+	APIKey         string        `yaml:"api_key"`
+	Regions        []string      `yaml:"regions"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+// SpeechmaticsRegions lists the region names internal/speechmatics has
+// endpoints for
+var SpeechmaticsRegions = []string{"eu", "us"}
+
+// TranscriptionConfig contains the defaults used when starting a transcription.
+// These fields can be changed at runtime through a reload.
+type TranscriptionConfig struct {
+	Language               string  `yaml:"language"`
+	OperatingPoint         string  `yaml:"operating_point"`
+	Diarization            string  `yaml:"diarization"`
+	DiarizationMaxSpeakers int     `yaml:"diarization_max_speakers"`
+	EnableEntities         bool    `yaml:"enable_entities"`
+	SampleRate             int     `yaml:"sample_rate"`
+	MaxDelay               float64 `yaml:"max_delay"`
+	// EndOfUtteranceSilence is the silence in seconds after which the
+	// engine signals the end of an utterance in streams that segment
+	// utterances; 0 disables the signal
+	EndOfUtteranceSilence float64       `yaml:"end_of_utterance_silence"`
+	ReadTimeout           time.Duration `yaml:"read_timeout"`
+	WriteTimeout          time.Duration `yaml:"write_timeout"`
+	MaxSessions           int           `yaml:"max_sessions"`
+}
+
+// BatchConfig contains limits for batch transcription jobs.
+// These fields can be changed at runtime through a reload.
+type BatchConfig struct {
+	MaxUploadBytes int64         `yaml:"max_upload_bytes"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	WaitTimeout    time.Duration `yaml:"wait_timeout"`
+}
+
+// WebConfig contains settings for the HTTP server in cmd/web
+type WebConfig struct {
+	Port         string        `yaml:"port"`
+	PublicDir    string        `yaml:"public_dir"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	// ReadinessDrainDelay is how long the server keeps serving after
+	// readiness reports draining, so that load balancers polling /readyz
+	// stop routing to it before it stops accepting connections
+	ReadinessDrainDelay time.Duration `yaml:"readiness_drain_delay"`
+	TLS                 TLSConfig     `yaml:"tls"`
+	// AllowedOrigins lists browser origins allowed to use the API and
+	// WebSocket endpoints; entries may contain wildcards ("https://*.example.com").
+	// Same-origin requests are always allowed.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// ProviderConfig contains settings for the gRPC server in cmd/pcas-provider
+type ProviderConfig struct {
+	GRPCPort    string    `yaml:"grpc_port"`
+	MetricsPort string    `yaml:"metrics_port"`
+	TLS         TLSConfig `yaml:"tls"`
+	// Events publishes transcript events to the PCAS event bus
+	Events EventBusConfig `yaml:"events"`
+}
+
+// EventBusConfig controls publishing of finalized segments and session
+// summaries as PCAS events. Publishing is enabled when Address is set.
+type EventBusConfig struct {
+	// Address is the PCAS gRPC endpoint, e.g. "pcas:50051"
+	Address  string `yaml:"address"`
+	Insecure bool   `yaml:"insecure"`
+	// SegmentType and SummaryType are the event types of finalized segments
+	// and session summaries; an empty type is not published. Summaries need
+	// session storage and a summarizer.
+	SegmentType string `yaml:"segment_type"`
+	SummaryType string `yaml:"summary_type"`
+	Source      string `yaml:"source"`
+	// UserID is the user of published events, unless a stream sets
+	// "user_id" in its configuration
+	UserID    string        `yaml:"user_id"`
+	Timeout   time.Duration `yaml:"timeout"`
+	QueueSize int           `yaml:"queue_size"`
+}
+
+// TLSConfig contains server certificate settings. TLS is enabled when
+// CertFile is set; client certificates are required when ClientCAFile is set.
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	AllowedSANs    []string      `yaml:"allowed_sans"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// RecordingConfig controls server-side archival of realtime session audio.
+// The web server and the provider must point at the same directory for the
+// recordings API to see what the provider records.
+type RecordingConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+	// Format is either "wav" or "flac"
+	Format string `yaml:"format"`
+	// MaxAge deletes recordings older than this; zero keeps them forever
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxTotalBytes deletes the oldest recordings once the archive grows past
+	// this size; zero means no limit
+	MaxTotalBytes   int64         `yaml:"max_total_bytes"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+// SessionsConfig controls server-side storage of transcription sessions. As
+// with recordings, the web server and the provider share the directory.
+type SessionsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+}
+
+// SpeakersConfig controls the registry of enrolled speaker profiles used
+// for speaker identification. The web server enrolls speakers and the
+// provider reads the same file when a session starts.
+type SpeakersConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	File    string `yaml:"file"`
+}
+
+// LiveConfig controls live broadcasts in cmd/web, where one producer streams
+// a transcript to many read-only subscribers
+type LiveConfig struct {
+	// BacklogSize is the number of final events replayed to late joiners
+	BacklogSize int `yaml:"backlog_size"`
+	// SubscriberBuffer is the number of events queued for a subscriber
+	// before it is disconnected as too slow
+	SubscriberBuffer int `yaml:"subscriber_buffer"`
+	// IdleTimeout removes broadcasts that have not received an event for this long
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+// TranslationConfig selects the machine translation backend that translates
+// batch transcripts and live broadcasts into languages the engine was not
+// asked for
+type TranslationConfig struct {
+	// Backend is "dictionary", "http", "speechmatics" or empty to disable
+	// translation. The speechmatics backend translates batch jobs as part
+	// of the job and cannot translate live broadcasts.
+	Backend string `yaml:"backend"`
+	// DictionaryFile holds the phrase tables of the dictionary backend
+	DictionaryFile string `yaml:"dictionary_file"`
+	// URL and APIKey address a LibreTranslate compatible service for the
+	// http backend
+	URL     string        `yaml:"url"`
+	APIKey  string        `yaml:"api_key"`
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxLanguages bounds the on-demand languages of one broadcast
+	MaxLanguages int `yaml:"max_languages"`
+}
+
+// SummaryConfig controls the meeting summaries of stored sessions
+type SummaryConfig struct {
+	// Summarizer is "local" or empty to disable summaries. Batch jobs that
+	// ask for it are summarized by Speechmatics either way.
+	Summarizer string `yaml:"summarizer"`
+	// OnEnd summarizes sessions when they end
+	OnEnd bool `yaml:"on_end"`
+	// MaxSentences bounds the length of a local summary
+	MaxSentences int           `yaml:"max_sentences"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// AlertsConfig defines the rules that raise alerts when phrases are said in
+// realtime sessions and live broadcasts
+type AlertsConfig struct {
+	Rules []AlertRule `yaml:"rules"`
+	// Webhooks receive every alert as a JSON POST
+	Webhooks []string      `yaml:"webhooks"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// AlertRule raises an alert when a final transcript matches one of its
+// patterns
+type AlertRule struct {
+	Name string `yaml:"name"`
+	// Match is "keyword" (default), "regex" or "fuzzy". Keywords and fuzzy
+	// patterns match whole words, ignoring case.
+	Match    string   `yaml:"match"`
+	Patterns []string `yaml:"patterns"`
+	// Speakers limits the rule to these speaker labels or names
+	Speakers []string `yaml:"speakers"`
+	// Cooldown suppresses repeated alerts of the rule in one session
+	Cooldown time.Duration `yaml:"cooldown"`
+	// MaxDistance is the number of character edits a fuzzy pattern
+	// tolerates; 0 only matches exactly. When it is not set, one edit per
+	// five characters of the pattern is allowed.
+	MaxDistance *int `yaml:"max_distance"`
+}
+
+// WebhooksConfig lists the external systems that receive transcript
+// lifecycle events
+type WebhooksConfig struct {
+	Sinks []WebhookSink `yaml:"sinks"`
+	// MaxAttempts bounds the deliveries of one event to one sink before it
+	// is moved to the dead letter queue
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryBackoff is the wait before the first retry, doubled after each
+	// failed attempt
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	Timeout      time.Duration `yaml:"timeout"`
+	// QueueSize is the number of events waiting per sink before new events
+	// are dropped
+	QueueSize int `yaml:"queue_size"`
+	// DeadLetterDir holds the events that could not be delivered
+	DeadLetterDir string `yaml:"dead_letter_dir"`
+	// LogSize is the number of recent deliveries kept for the delivery log
+	LogSize int `yaml:"log_size"`
+}
+
+// WebhookSink is an HTTP endpoint receiving events as signed JSON POSTs
+type WebhookSink struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Secret signs deliveries with HMAC-SHA256; empty sends them unsigned
+	Secret string `yaml:"secret"`
+	// Events lists the event types to deliver, such as "segment.final" or
+	// "session.*"; empty delivers every event
+	Events []string `yaml:"events"`
+}
+
+// TracingConfig contains OpenTelemetry export settings
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Default returns a configuration populated with the built-in defaults
+func Default() *Config {
+	return &Config{
+		Speechmatics: SpeechmaticsConfig{
+			Regions:        []string{"eu"},
+			TokenTTL:       10 * time.Minute,
+			RequestTimeout: 30 * time.Second,
+		},
+		Transcription: TranscriptionConfig{
+			Language:               "en",
+			OperatingPoint:         "enhanced",
+			Diarization:            "speaker",
+			DiarizationMaxSpeakers: 10,
+			EnableEntities:         true,
+			SampleRate:             48000,
+			EndOfUtteranceSilence:  0.8,
+			ReadTimeout:            60 * time.Second,
+			WriteTimeout:           10 * time.Second,
+			MaxSessions:            100,
+		},
+		Batch: BatchConfig{
+			MaxUploadBytes: 100 << 20,
+			PollInterval:   2 * time.Second,
+			WaitTimeout:    10 * time.Minute,
+		},
+		Web: WebConfig{
+			Port:         "8080",
+			PublicDir:    "./public",
+			ReadTimeout:  5 * time.Minute,  // Increased for file uploads
+			WriteTimeout: 15 * time.Minute, // Increased for batch processing
+			IdleTimeout:  60 * time.Second,
+			DrainTimeout: 30 * time.Second,
+			// One readiness probe period of a typical load balancer
+			ReadinessDrainDelay: 5 * time.Second,
+			TLS:                 TLSConfig{ReloadInterval: 30 * time.Second},
+			// Vite dev server
+			AllowedOrigins: []string{"http://localhost:5173"},
+		},
+		Provider: ProviderConfig{
+			GRPCPort:    "50051",
+			MetricsPort: "9091",
+			TLS:         TLSConfig{ReloadInterval: 30 * time.Second},
+			Events: EventBusConfig{
+				SegmentType: "dreamtrans.transcript.segment.v1",
+				SummaryType: "dreamtrans.session.summary.v1",
+				Source:      "/d-app/dreamtrans",
+				Timeout:     5 * time.Second,
+				QueueSize:   1000,
+			},
+		},
+		Log: logging.Config{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "localhost:4317",
+			Insecure:    true,
+			SampleRatio: 1,
+		},
+		Recording: RecordingConfig{
+			Dir:             "./data/recordings",
+			Format:          "wav",
+			MaxAge:          30 * 24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+		Sessions: SessionsConfig{
+			Dir: "./data/sessions",
+		},
+		Speakers: SpeakersConfig{
+			File: "./data/speakers.json",
+		},
+		Live: LiveConfig{
+			BacklogSize:      2000,
+			SubscriberBuffer: 256,
+			IdleTimeout:      time.Hour,
+		},
+		Translation: TranslationConfig{
+			Timeout:      10 * time.Second,
+			MaxLanguages: 5,
+		},
+		Alerts: AlertsConfig{
+			Timeout: 5 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:   5,
+			RetryBackoff:  time.Second,
+			Timeout:       10 * time.Second,
+			QueueSize:     1000,
+			DeadLetterDir: "./data/webhooks",
+			LogSize:       1000,
+		},
+		Summary: SummaryConfig{
+			OnEnd:        true,
+			MaxSentences: 5,
+			Timeout:      time.Minute,
+		},
+	}
+}
+
+// Load reads the configuration file at path (if any), applies environment
+// variable overrides and validates the result
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides file values with environment variables
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("SM_API_KEY"); v != "" {
+		c.Speechmatics.APIKey = v
+	}
+	if v := os.Getenv("SM_REGIONS"); v != "" {
+		c.Speechmatics.Regions = splitList(v)
+	}
+	if v := os.Getenv("PORT"); v != "" {
+		c.Web.Port = v
+	}
+	if v := os.Getenv("PUBLIC_DIR"); v != "" {
+		c.Web.PublicDir = v
+	}
+	if v := os.Getenv("ALLOWED_ORIGINS"); v != "" {
+		c.Web.AllowedOrigins = splitList(v)
+	}
+	if v := os.Getenv("GRPC_PORT"); v != "" {
+		c.Provider.GRPCPort = v
+	}
+	if v := os.Getenv("METRICS_PORT"); v != "" {
+		c.Provider.MetricsPort = v
+	}
+	if v := os.Getenv("TLS_CERT_FILE"); v != "" {
+		c.Web.TLS.CertFile = v
+		c.Provider.TLS.CertFile = v
+	}
+	if v := os.Getenv("TLS_KEY_FILE"); v != "" {
+		c.Web.TLS.KeyFile = v
+		c.Provider.TLS.KeyFile = v
+	}
+	if v := os.Getenv("GRPC_CLIENT_CA_FILE"); v != "" {
+		c.Provider.TLS.ClientCAFile = v
+	}
+	if v := os.Getenv("PCAS_EVENTS_ADDRESS"); v != "" {
+		c.Provider.Events.Address = v
+	}
+	if v := os.Getenv("PCAS_EVENTS_INSECURE"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid PCAS_EVENTS_INSECURE: %w", err)
+		}
+		c.Provider.Events.Insecure = insecure
+	}
+	if v := os.Getenv("PCAS_SEGMENT_EVENT_TYPE"); v != "" {
+		c.Provider.Events.SegmentType = v
+	}
+	if v := os.Getenv("PCAS_SUMMARY_EVENT_TYPE"); v != "" {
+		c.Provider.Events.SummaryType = v
+	}
+	if v := os.Getenv("PCAS_USER_ID"); v != "" {
+		c.Provider.Events.UserID = v
+	}
+	if v := os.Getenv("SM_OPERATING_POINT"); v != "" {
+		c.Transcription.OperatingPoint = v
+	}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		c.Log.Level = v
+	}
+	if v := os.Getenv("LOG_FORMAT"); v != "" {
+		c.Log.Format = v
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		c.Tracing.Enabled = true
+		c.Tracing.Endpoint = strings.TrimPrefix(strings.TrimPrefix(v, "http://"), "https://")
+	}
+	if v := os.Getenv("RECORDING_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid RECORDING_ENABLED: %w", err)
+		}
+		c.Recording.Enabled = enabled
+	}
+	if v := os.Getenv("RECORDING_DIR"); v != "" {
+		c.Recording.Dir = v
+	}
+	if v := os.Getenv("SESSIONS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid SESSIONS_ENABLED: %w", err)
+		}
+		c.Sessions.Enabled = enabled
+	}
+	if v := os.Getenv("SESSIONS_DIR"); v != "" {
+		c.Sessions.Dir = v
+	}
+	if v := os.Getenv("SPEAKERS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid SPEAKERS_ENABLED: %w", err)
+		}
+		c.Speakers.Enabled = enabled
+	}
+	if v := os.Getenv("SPEAKERS_FILE"); v != "" {
+		c.Speakers.File = v
+	}
+	if v := os.Getenv("TRANSLATION_BACKEND"); v != "" {
+		c.Translation.Backend = v
+	}
+	if v := os.Getenv("TRANSLATION_DICTIONARY_FILE"); v != "" {
+		c.Translation.DictionaryFile = v
+	}
+	if v := os.Getenv("TRANSLATION_URL"); v != "" {
+		c.Translation.URL = v
+	}
+	if v := os.Getenv("TRANSLATION_API_KEY"); v != "" {
+		c.Translation.APIKey = v
+	}
+	if v := os.Getenv("SUMMARY_SUMMARIZER"); v != "" {
+		c.Summary.Summarizer = v
+	}
+	if v := os.Getenv("SUMMARY_ON_END"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid SUMMARY_ON_END: %w", err)
+		}
+		c.Summary.OnEnd = b
+	}
+	if v := os.Getenv("ALERT_WEBHOOKS"); v != "" {
+		c.Alerts.Webhooks = splitList(v)
+	}
+	if v := os.Getenv("WEBHOOK_URL"); v != "" {
+		c.Webhooks.Sinks = append(c.Webhooks.Sinks, WebhookSink{
+			Name:   "default",
+			URL:    v,
+			Secret: os.Getenv("WEBHOOK_SECRET"),
+			Events: splitList(os.Getenv("WEBHOOK_EVENTS")),
+		})
+	}
+	if v := os.Getenv("WEBHOOK_DEAD_LETTER_DIR"); v != "" {
+		c.Webhooks.DeadLetterDir = v
+	}
+	if v := os.Getenv("SM_MAX_SPEAKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid SM_MAX_SPEAKERS: %w", err)
+		}
+		c.Transcription.DiarizationMaxSpeakers = n
+	}
+	return nil
+}
+
+// Validate checks that the configuration is usable
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Speechmatics.APIKey == "" {
+		errs = append(errs, errors.New("speechmatics.api_key (SM_API_KEY) is required"))
+	}
+	if err := validateRegions(c.Speechmatics.Regions); err != nil {
+		errs = append(errs, fmt.Errorf("speechmatics.regions: %w", err))
+	}
+	if c.Speechmatics.TokenTTL < time.Minute {
+		errs = append(errs, errors.New("speechmatics.token_ttl must be at least 1m"))
+	}
+	if c.Speechmatics.RequestTimeout <= 0 {
+		errs = append(errs, errors.New("speechmatics.request_timeout must be positive"))
+	}
+
+	t := c.Transcription
+	if t.Language == "" {
+		errs = append(errs, errors.New("transcription.language is required"))
+	}
+	if t.OperatingPoint != "standard" && t.OperatingPoint != "enhanced" {
+		errs = append(errs, fmt.Errorf("transcription.operating_point must be standard or enhanced, got %q", t.OperatingPoint))
+	}
+	if t.Diarization != "none" && t.Diarization != "speaker" {
+		errs = append(errs, fmt.Errorf("transcription.diarization must be none or speaker, got %q", t.Diarization))
+	}
+	if t.DiarizationMaxSpeakers < 2 || t.DiarizationMaxSpeakers > 100 {
+		errs = append(errs, fmt.Errorf("transcription.diarization_max_speakers must be between 2 and 100, got %d", t.DiarizationMaxSpeakers))
+	}
+	if t.SampleRate <= 0 {
+		errs = append(errs, errors.New("transcription.sample_rate must be positive"))
+	}
+	if t.MaxDelay < 0 {
+		errs = append(errs, errors.New("transcription.max_delay must not be negative"))
+	}
+	if t.EndOfUtteranceSilence < 0 || t.EndOfUtteranceSilence > 2 {
+		errs = append(errs, errors.New("transcription.end_of_utterance_silence must be between 0 and 2 seconds"))
+	}
+	if t.ReadTimeout <= 0 || t.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("transcription read and write timeouts must be positive"))
+	}
+	if t.MaxSessions <= 0 {
+		errs = append(errs, errors.New("transcription.max_sessions must be positive"))
+	}
+
+	if c.Batch.MaxUploadBytes <= 0 {
+		errs = append(errs, errors.New("batch.max_upload_bytes must be positive"))
+	}
+	if c.Batch.PollInterval <= 0 || c.Batch.WaitTimeout <= 0 {
+		errs = append(errs, errors.New("batch poll interval and wait timeout must be positive"))
+	}
+
+	if c.Web.DrainTimeout < 0 {
+		errs = append(errs, errors.New("web.drain_timeout must not be negative"))
+	}
+	if c.Web.ReadinessDrainDelay < 0 {
+		errs = append(errs, errors.New("web.readiness_drain_delay must not be negative"))
+	}
+	for _, o := range c.Web.AllowedOrigins {
+		if strings.TrimSpace(o) == "*" {
+			errs = append(errs, errors.New("web.allowed_origins must not contain \"*\""))
+		}
+	}
+	if err := validatePort(c.Web.Port); err != nil {
+		errs = append(errs, fmt.Errorf("web.port: %w", err))
+	}
+	if err := validatePort(c.Provider.GRPCPort); err != nil {
+		errs = append(errs, fmt.Errorf("provider.grpc_port: %w", err))
+	}
+	if err := validatePort(c.Provider.MetricsPort); err != nil {
+		errs = append(errs, fmt.Errorf("provider.metrics_port: %w", err))
+	}
+	if err := c.Web.TLS.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("web.tls: %w", err))
+	}
+	if err := c.Provider.TLS.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("provider.tls: %w", err))
+	}
+	if err := c.Provider.Events.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("provider.events: %w", err))
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	for name, level := range c.Log.Components {
+		if _, err := logging.ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("log.components.%s: %w", name, err))
+		}
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("log.format must be json or text, got %q", c.Log.Format))
+	}
+
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		errs = append(errs, errors.New("tracing.endpoint is required when tracing is enabled"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	if r := c.Recording; r.Enabled {
+		if r.Dir == "" {
+			errs = append(errs, errors.New("recording.dir is required when recording is enabled"))
+		}
+		if r.Format != "wav" && r.Format != "flac" {
+			errs = append(errs, fmt.Errorf("recording.format must be wav or flac, got %q", r.Format))
+		}
+		if r.MaxAge < 0 || r.MaxTotalBytes < 0 {
+			errs = append(errs, errors.New("recording retention limits must not be negative"))
+		}
+		if r.CleanupInterval <= 0 {
+			errs = append(errs, errors.New("recording.cleanup_interval must be positive"))
+		}
+	}
+
+	if c.Sessions.Enabled && c.Sessions.Dir == "" {
+		errs = append(errs, errors.New("sessions.dir is required when session storage is enabled"))
+	}
+	if c.Live.BacklogSize <= 0 || c.Live.SubscriberBuffer <= 0 || c.Live.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("live.backlog_size, live.subscriber_buffer and live.idle_timeout must be positive"))
+	}
+	switch c.Translation.Backend {
+	case "", "dictionary", "speechmatics":
+	case "http":
+		if c.Translation.URL == "" {
+			errs = append(errs, errors.New("translation.url is required for the http translation backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown translation.backend %q", c.Translation.Backend))
+	}
+	if c.Translation.Backend != "" && (c.Translation.Timeout <= 0 || c.Translation.MaxLanguages <= 0) {
+		errs = append(errs, errors.New("translation.timeout and translation.max_languages must be positive"))
+	}
+	switch c.Summary.Summarizer {
+	case "", "local":
+	default:
+		errs = append(errs, fmt.Errorf("unknown summary.summarizer %q", c.Summary.Summarizer))
+	}
+	if c.Summary.MaxSentences <= 0 || c.Summary.Timeout <= 0 {
+		errs = append(errs, errors.New("summary.max_sentences and summary.timeout must be positive"))
+	}
+	if err := c.Alerts.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Webhooks.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Speakers.Enabled {
+		if c.Speakers.File == "" {
+			errs = append(errs, errors.New("speakers.file is required when speaker identification is enabled"))
+		}
+		if c.Transcription.Diarization != "speaker" {
+			errs = append(errs, fmt.Errorf("speaker identification requires transcription.diarization \"speaker\", got %q", c.Transcription.Diarization))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate checks that TLS settings are complete
+func (t TLSConfig) validate() error {
+	if t.CertFile == "" {
+		if t.KeyFile != "" || t.ClientCAFile != "" || len(t.AllowedSANs) > 0 {
+			return errors.New("cert_file is required when other TLS settings are set")
+		}
+		return nil
+	}
+	if t.KeyFile == "" {
+		return errors.New("key_file is required with cert_file")
+	}
+	if len(t.AllowedSANs) > 0 && t.ClientCAFile == "" {
+		return errors.New("client_ca_file is required with allowed_sans")
+	}
+	if t.ReloadInterval <= 0 {
+		return errors.New("reload_interval must be positive")
+	}
+	return nil
+}
+
+// validateRegions checks that regions names only known regions, and at least one.
+// Names are matched like speechmatics.LookupRegions matches them.
+func validateRegions(regions []string) error {
+	var errs []error
+	named := false
+	for _, name := range regions {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		named = true
+		if !slices.Contains(SpeechmaticsRegions, name) {
+			errs = append(errs, fmt.Errorf("unknown region %q, want one of %s", name, strings.Join(SpeechmaticsRegions, ", ")))
+		}
+	}
+	if !named {
+		errs = append(errs, errors.New("must list at least one region"))
+	}
+	return errors.Join(errs...)
+}
+
+// validatePort checks that a port is a number in the valid TCP range
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list and drops empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validate checks the alert rules and webhooks
+func (a AlertsConfig) validate() error {
+	var errs []error
+	names := make(map[string]bool, len(a.Rules))
+	for i, rule := range a.Rules {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("alerts.rules[%d].name is required", i))
+		} else if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("duplicate alert rule %q", rule.Name))
+		}
+		names[rule.Name] = true
+		if len(rule.Patterns) == 0 {
+			errs = append(errs, fmt.Errorf("alert rule %q has no patterns", rule.Name))
+		}
+		switch rule.Match {
+		case "", "keyword", "fuzzy":
+		case "regex":
+			for _, p := range rule.Patterns {
+				if _, err := regexp.Compile(p); err != nil {
+					errs = append(errs, fmt.Errorf("alert rule %q: %w", rule.Name, err))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("alert rule %q: unknown match %q", rule.Name, rule.Match))
+		}
+		if rule.Cooldown < 0 || (rule.MaxDistance != nil && *rule.MaxDistance < 0) {
+			errs = append(errs, fmt.Errorf("alert rule %q: cooldown and max_distance must not be negative", rule.Name))
+		}
+	}
+	for _, hook := range a.Webhooks {
+		if u, err := url.Parse(hook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid alert webhook %q", hook))
+		}
+	}
+	if len(a.Webhooks) > 0 && a.Timeout <= 0 {
+		errs = append(errs, errors.New("alerts.timeout must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks the event types and publishing settings
+func (e EventBusConfig) validate() error {
+	if e.Address == "" {
+		return nil
+	}
+	var errs []error
+	if e.SegmentType == "" && e.SummaryType == "" {
+		errs = append(errs, errors.New("segment_type or summary_type is required when an address is set"))
+	}
+	if e.Source == "" {
+		errs = append(errs, errors.New("source is required when an address is set"))
+	}
+	if e.Timeout <= 0 || e.QueueSize <= 0 {
+		errs = append(errs, errors.New("timeout and queue_size must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks the webhook sinks and delivery settings
+func (w WebhooksConfig) validate() error {
+	if len(w.Sinks) == 0 {
+		return nil
+	}
+	var errs []error
+	names := make(map[string]bool, len(w.Sinks))
+	for i, sink := range w.Sinks {
+		if sink.Name == "" {
+			errs = append(errs, fmt.Errorf("webhooks.sinks[%d].name is required", i))
+		} else if names[sink.Name] {
+			errs = append(errs, fmt.Errorf("duplicate webhook sink %q", sink.Name))
+		}
+		names[sink.Name] = true
+		if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhook sink %q: invalid url %q", sink.Name, sink.URL))
+		}
+	}
+	if w.MaxAttempts <= 0 || w.RetryBackoff <= 0 || w.Timeout <= 0 || w.QueueSize <= 0 || w.LogSize <= 0 {
+		errs = append(errs, errors.New("webhooks.max_attempts, retry_backoff, timeout, queue_size and log_size must be positive"))
+	}
+	if w.DeadLetterDir == "" {
+		errs = append(errs, errors.New("webhooks.dead_letter_dir is required when webhook sinks are configured"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRegionsAndDiarization(t *testing.T) {
+	tests := []struct {
+		name        string
+		regions     []string
+		diarization string
+		wantErr     string
+	}{
+		{"defaults", []string{"eu"}, "speaker", ""},
+		{"both regions", []string{"us", "eu"}, "speaker", ""},
+		{"region case and spaces", []string{" US "}, "speaker", ""},
+		{"no diarization", []string{"eu"}, "none", ""},
+		{"no regions", nil, "speaker", "must list at least one region"},
+		{"empty region names", []string{"", " "}, "speaker", "must list at least one region"},
+		{"unknown region", []string{"eu", "ap"}, "speaker", `unknown region "ap"`},
+		{"unknown diarization", []string{"eu"}, "speakers", `transcription.diarization must be none or speaker, got "speakers"`},
+		{"empty diarization", []string{"eu"}, "", "transcription.diarization"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Speechmatics.APIKey = "key"
+			cfg.Speechmatics.Regions = tt.regions
+			cfg.Transcription.Diarization = tt.diarization
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/dreamtrans/backend/internal/logging"
+)
+
+// Manager holds the active configuration and reloads it on SIGHUP.
+// Only the transcription, batch and log level settings are applied on reload;
+// changes to any other section are reported and require a restart.
+type Manager struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+// NewManager loads and validates the configuration at path
+func NewManager(path string) (*Manager, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{path: path}
+	m.current.Store(cfg)
+	return m, nil
+}
+
+// Current returns the active configuration. The returned value must not be modified.
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// OnReload registers a function that is called with the new configuration
+// after every successful reload
+func (m *Manager) OnReload(fn func(*Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Reload re-reads the configuration and applies the fields that are safe to
+// change while running. The active configuration is kept if the new one is invalid.
+func (m *Manager) Reload() error {
+	next, err := Load(m.path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.current.Load()
+	merged := *prev
+	merged.Transcription = next.Transcription
+	merged.Batch = next.Batch
+	merged.Log.Level = next.Log.Level
+	merged.Log.Components = next.Log.Components
+
+	next.Transcription = prev.Transcription
+	next.Batch = prev.Batch
+	next.Log.Level = prev.Log.Level
+	next.Log.Components = prev.Log.Components
+	if !reflect.DeepEqual(next, prev) {
+		slog.Warn("Configuration changes outside transcription, batch and log level settings require a restart and were ignored")
+	}
+
+	if err := logging.Apply(merged.Log); err != nil {
+		return err
+	}
+
+	m.current.Store(&merged)
+	for _, fn := range m.listeners {
+		fn(&merged)
+	}
+	return nil
+}
+
+// WatchSignals reloads the configuration whenever the process receives SIGHUP,
+// until ctx is canceled
+func (m *Manager) WatchSignals(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigChan:
+				if err := m.Reload(); err != nil {
+					slog.Error("Configuration reload failed, keeping current configuration", "error", err)
+					continue
+				}
+				slog.Info("Configuration reloaded")
+			}
+		}
+	}()
+}
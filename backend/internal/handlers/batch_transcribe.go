@@ -1,15 +1,26 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/speakers"
 	"github.com/dreamtrans/backend/internal/speechmatics"
+	"github.com/dreamtrans/backend/internal/summarize"
+	"github.com/dreamtrans/backend/internal/translate"
+	"github.com/dreamtrans/backend/internal/webhooks"
 )
 
 // BatchTranscribeRequest represents the request body for batch transcription
@@ -18,6 +29,10 @@ type BatchTranscribeRequest struct {
 	Diarization    string  `json:"diarization"`
 	OperatingPoint string  `json:"operating_point"`
 	MaxDelay       float64 `json:"max_delay"`
+	// TargetLanguages translates the transcript into these languages
+	TargetLanguages []string `json:"target_languages"`
+	// Summarize asks Speechmatics to summarize the transcript
+	Summarize bool `json:"summarize"`
 }
 
 // BatchTranscribeResponse represents the response for batch transcription
@@ -25,24 +40,409 @@ type BatchTranscribeResponse struct {
 	JobID      string                           `json:"job_id"`
 	Status     string                           `json:"status"`
 	Transcript *speechmatics.TranscriptResponse `json:"transcript,omitempty"`
-	Error      string                           `json:"error,omitempty"`
+	// Translations holds the translated segments by language, with the
+	// speakers and timing of the transcript
+	Translations map[string][]translate.Segment `json:"translations,omitempty"`
+	Error        string                         `json:"error,omitempty"`
 }
 
 // BatchTranscribeHandler handles batch transcription requests
 type BatchTranscribeHandler struct {
+	cfg         *config.Manager
+	logger      *slog.Logger
 	batchClient *speechmatics.BatchClient
+	sessions    *sessions.Store
+	profiles    *speakers.Registry
+	translator  translate.Translator
+	summarizer  summarize.Summarizer
+	webhooks    *webhooks.Dispatcher
+
+	// targets holds the translation languages of submitted jobs that are
+	// translated after transcription, by job ID
+	targets sync.Map
+	// results holds the *finishedJob of each job whose transcript was
+	// finished, so that polling a job does not translate it again
+	results sync.Map
+}
+
+// BatchOption configures optional BatchTranscribeHandler features
+type BatchOption func(*BatchTranscribeHandler)
+
+// WithBatchSessions stores completed transcripts and their translations as
+// sessions in store
+func WithBatchSessions(store *sessions.Store) BatchOption {
+	return func(h *BatchTranscribeHandler) {
+		h.sessions = store
+	}
+}
+
+// WithBatchSpeakerProfiles identifies enrolled speakers and, together with
+// WithBatchSessions, stores the speaker identifiers of jobs for enrollment
+func WithBatchSpeakerProfiles(registry *speakers.Registry) BatchOption {
+	return func(h *BatchTranscribeHandler) {
+		h.profiles = registry
+	}
+}
+
+// WithBatchTranslator translates transcripts into the target languages that
+// Speechmatics does not translate
+func WithBatchTranslator(t translate.Translator) BatchOption {
+	return func(h *BatchTranscribeHandler) {
+		h.translator = t
+	}
+}
+
+// WithBatchSummarizer summarizes stored transcripts. It has no effect
+// without WithBatchSessions.
+func WithBatchSummarizer(s summarize.Summarizer) BatchOption {
+	return func(h *BatchTranscribeHandler) {
+		h.summarizer = s
+	}
+}
+
+// WithBatchWebhooks announces finished jobs to webhook sinks
+func WithBatchWebhooks(d *webhooks.Dispatcher) BatchOption {
+	return func(h *BatchTranscribeHandler) {
+		h.webhooks = d
+	}
 }
 
 // NewBatchTranscribeHandler creates a new batch transcribe handler
-func NewBatchTranscribeHandler() (*BatchTranscribeHandler, error) {
-	apiKey := os.Getenv("SM_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("SM_API_KEY environment variable not set")
+func NewBatchTranscribeHandler(cfg *config.Manager, opts ...BatchOption) (*BatchTranscribeHandler, error) {
+	batchClient, err := speechmatics.NewBatchClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch client: %w", err)
+	}
+
+	h := &BatchTranscribeHandler{
+		cfg:         cfg,
+		logger:      logging.For("web"),
+		batchClient: batchClient,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h, nil
+}
+
+// speakerConfig returns the speaker identification settings for a job, or
+// nil when speaker profiles are disabled or the job does not diarize speakers
+func (h *BatchTranscribeHandler) speakerConfig(ctx context.Context, diarization string) *speechmatics.SpeakerDiarizationConfig {
+	if h.profiles == nil || diarization != "speaker" {
+		return nil
+	}
+	known, err := h.profiles.Known()
+	if err != nil {
+		// Fall back to plain diarization
+		h.logger.ErrorContext(ctx, "Failed to load speaker profiles", "error", err)
+		return nil
+	}
+	return &speechmatics.SpeakerDiarizationConfig{
+		Speakers: known,
+		// Identifiers are only useful if they can be enrolled later
+		GetSpeakers: h.sessions != nil,
+	}
+}
 
-	return &BatchTranscribeHandler{
-		batchClient: speechmatics.NewBatchClient(apiKey),
-	}, nil
+// translationConfig returns the translation settings for a job. Speechmatics
+// translates as part of the job; other backends translate the transcript
+// when the job is done.
+func (h *BatchTranscribeHandler) translationConfig(targets []string) (*speechmatics.TranslationConfig, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	switch {
+	case h.cfg.Current().Translation.Backend == "speechmatics":
+		return &speechmatics.TranslationConfig{TargetLanguages: targets}, nil
+	case h.translator == nil:
+		return nil, errors.New("translation is disabled")
+	}
+	return nil, nil
+}
+
+// jobConfig returns the Speechmatics job settings of a request. Unset
+// fields of the request are set to the configured defaults.
+func (h *BatchTranscribeHandler) jobConfig(ctx context.Context, req *BatchTranscribeRequest) (*speechmatics.JobConfig, error) {
+	defaults := h.cfg.Current().Transcription
+	if req.Language == "" {
+		req.Language = defaults.Language
+	}
+	if req.Diarization == "" {
+		req.Diarization = defaults.Diarization
+	}
+	if req.OperatingPoint == "" {
+		req.OperatingPoint = defaults.OperatingPoint
+	}
+
+	translationConfig, err := h.translationConfig(req.TargetLanguages)
+	if err != nil {
+		return nil, err
+	}
+	jobConfig := &speechmatics.JobConfig{
+		Type: "transcription",
+		TranscriptionConfig: speechmatics.TranscriptionConfig{
+			Language:       req.Language,
+			Diarization:    req.Diarization,
+			EnablePartials: true,
+			OperatingPoint: req.OperatingPoint,
+			MaxDelay:       req.MaxDelay,
+
+			SpeakerDiarizationConfig: h.speakerConfig(ctx, req.Diarization),
+		},
+		TranslationConfig: translationConfig,
+	}
+	if req.Summarize {
+		jobConfig.SummarizationConfig = &speechmatics.SummarizationConfig{
+			ContentType:   "auto",
+			SummaryLength: "brief",
+			SummaryType:   "paragraphs",
+		}
+	}
+	return jobConfig, nil
+}
+
+// resultTTL is how long the translations of a finished job are kept in
+// memory. Stored transcripts are read from the session store instead.
+const resultTTL = time.Hour
+
+// finishedJob is the result of finishing a job's transcript
+type finishedJob struct {
+	created      time.Time
+	once         sync.Once
+	translations map[string][]translate.Segment
+}
+
+// finishTranscript translates a completed transcript and stores it. Each
+// job is finished once; later calls return the stored translations, or the
+// translations kept in memory when session storage is disabled.
+func (h *BatchTranscribeHandler) finishTranscript(ctx context.Context, jobID string, transcript *speechmatics.TranscriptResponse) map[string][]translate.Segment {
+	if h.sessions != nil {
+		if s, err := h.sessions.Get("batch-" + jobID); err == nil {
+			return storedTranslations(s)
+		}
+	}
+
+	v, loaded := h.results.LoadOrStore(jobID, &finishedJob{created: time.Now()})
+	job := v.(*finishedJob)
+	job.once.Do(func() {
+		// The result is shared, so it must not depend on the caller staying
+		ctx := context.WithoutCancel(ctx)
+		var targets []string
+		if v, ok := h.targets.LoadAndDelete(jobID); ok {
+			targets = v.([]string)
+		}
+		job.translations = h.translateTranscript(ctx, jobID, transcript, targets)
+		h.storeTranscript(ctx, jobID, transcript, job.translations)
+	})
+	if !loaded {
+		h.pruneResults()
+	}
+	return job.translations
+}
+
+// pruneResults forgets the results of jobs finished more than resultTTL ago
+func (h *BatchTranscribeHandler) pruneResults() {
+	cutoff := time.Now().Add(-resultTTL)
+	h.results.Range(func(id, v interface{}) bool {
+		if v.(*finishedJob).created.Before(cutoff) {
+			h.results.Delete(id)
+		}
+		return true
+	})
+}
+
+// watchJob finishes a submitted job in the background once it is done and
+// emits its batch.job.done event, whether or not a client polls the job.
+// Jobs are only watched when webhook sinks are configured; a job that does
+// not complete within the wait timeout emits nothing.
+func (h *BatchTranscribeHandler) watchJob(jobID string) {
+	if h.webhooks == nil {
+		return
+	}
+	go func() {
+		ctx := logging.WithSessionID(context.Background(), jobID)
+		if err := h.batchClient.WaitForCompletion(ctx, jobID, h.cfg.Current().Batch.WaitTimeout); err != nil {
+			h.targets.Delete(jobID)
+			h.logger.WarnContext(ctx, "Batch job did not complete", "job_id", jobID, "error", err)
+			return
+		}
+		transcript, err := h.batchClient.GetTranscript(ctx, jobID, "json-v2")
+		if err != nil {
+			h.targets.Delete(jobID)
+			h.logger.ErrorContext(ctx, "Failed to get batch transcript", "job_id", jobID, "error", err)
+			return
+		}
+		translations := h.finishTranscript(ctx, jobID, transcript)
+		h.emitJobDone(ctx, jobID, transcript, translations)
+	}()
+}
+
+// emitJobDone emits the batch.job.done event of a finished job
+func (h *BatchTranscribeHandler) emitJobDone(ctx context.Context, jobID string, transcript *speechmatics.TranscriptResponse, translations map[string][]translate.Segment) {
+	job := webhooks.BatchJob{
+		JobID:      jobID,
+		DataName:   transcript.Job.DataName,
+		Language:   transcript.Language(),
+		Duration:   transcript.Metadata.Duration,
+		Summarized: transcript.Summary != nil,
+	}
+	for lang := range translations {
+		job.Translations = append(job.Translations, lang)
+	}
+	sort.Strings(job.Translations)
+	var sessionID string
+	if h.sessions != nil {
+		if s, err := h.sessions.Get("batch-" + jobID); err == nil {
+			sessionID = s.ID
+			job.Summarized = s.MeetingSummary != nil
+		}
+	}
+	h.webhooks.Emit(ctx, webhooks.EventBatchJobDone, sessionID, job)
+}
+
+// translateTranscript returns the translations Speechmatics made in the job
+// and translates the sentences of the transcript into the other targets
+func (h *BatchTranscribeHandler) translateTranscript(ctx context.Context, jobID string, transcript *speechmatics.TranscriptResponse, targets []string) map[string][]translate.Segment {
+	translations := make(map[string][]translate.Segment)
+	for lang, sentences := range transcript.Translations {
+		for _, sentence := range sentences {
+			translations[lang] = append(translations[lang], translate.Segment{
+				Speaker:   sentence.Speaker,
+				Text:      sentence.Content,
+				StartTime: sentence.StartTime,
+				EndTime:   sentence.EndTime,
+			})
+		}
+	}
+	if h.translator == nil {
+		return translations
+	}
+
+	var segments []translate.Segment
+	for _, sentence := range transcript.Sentences() {
+		segments = append(segments, translate.Segment{
+			Speaker:   sentence.Speaker,
+			Text:      sentence.Text,
+			StartTime: sentence.StartTime,
+			EndTime:   sentence.EndTime,
+		})
+	}
+	for _, lang := range targets {
+		if _, ok := translations[lang]; ok || len(segments) == 0 {
+			continue
+		}
+		translated, err := translate.Segments(ctx, h.translator, segments, transcript.Language(), lang)
+		metrics.Translations.WithLabelValues(metrics.Outcome(err)).Add(float64(len(segments)))
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to translate batch transcript", "job_id", jobID, "language", lang, "error", err)
+			continue
+		}
+		translations[lang] = translated
+	}
+	return translations
+}
+
+// storedTranslations groups the translations of a stored session by language
+func storedTranslations(s *sessions.Session) map[string][]translate.Segment {
+	translations := make(map[string][]translate.Segment)
+	for _, t := range s.Translations {
+		translations[t.Language] = append(translations[t.Language], translate.Segment{
+			Speaker:   t.Speaker,
+			Text:      t.Text,
+			StartTime: t.StartTime,
+			EndTime:   t.EndTime,
+		})
+	}
+	return translations
+}
+
+// storeTranscript saves a completed transcript and its translations as a
+// session so that it can be listed, searched and exported like realtime
+// sessions. Storing is idempotent per job.
+func (h *BatchTranscribeHandler) storeTranscript(ctx context.Context, jobID string, transcript *speechmatics.TranscriptResponse, translations map[string][]translate.Segment) {
+	if h.sessions == nil {
+		return
+	}
+
+	s := &sessions.Session{
+		ID:       "batch-" + jobID,
+		Title:    transcript.Job.DataName,
+		Source:   sessions.SourceBatch,
+		Language: transcript.Language(),
+		Metadata: map[string]string{"job_id": jobID},
+	}
+	if created, err := time.Parse(time.RFC3339, transcript.Job.CreatedAt); err == nil {
+		s.StartedAt = created.UTC()
+	}
+	for _, sentence := range transcript.Sentences() {
+		s.Segments = append(s.Segments, sessions.Segment{
+			Speaker:   sentence.Speaker,
+			Text:      sentence.Text,
+			StartTime: sentence.StartTime,
+			EndTime:   sentence.EndTime,
+		})
+	}
+	if len(transcript.Speakers) > 0 {
+		s.SpeakerIdentifiers = make(map[string][]string, len(transcript.Speakers))
+		for _, sp := range transcript.Speakers {
+			s.SpeakerIdentifiers[sp.Label] = sp.Identifiers
+		}
+	}
+	for lang, segments := range translations {
+		for _, seg := range segments {
+			s.Translations = append(s.Translations, sessions.Translation{
+				Language:  lang,
+				Speaker:   seg.Speaker,
+				Text:      seg.Text,
+				StartTime: seg.StartTime,
+				EndTime:   seg.EndTime,
+			})
+		}
+	}
+	ended := time.Now().UTC()
+	s.EndedAt = &ended
+	s.MeetingSummary = h.meetingSummary(ctx, jobID, transcript, s)
+
+	err := h.sessions.Create(s)
+	switch {
+	case errors.Is(err, sessions.ErrExists):
+	case err != nil:
+		h.logger.ErrorContext(ctx, "Failed to store batch transcript", "job_id", jobID, "error", err)
+	default:
+		h.logger.InfoContext(ctx, "Stored batch transcript", "job_id", jobID, "stored_session_id", s.ID)
+	}
+}
+
+// meetingSummary returns the summary of a batch transcript: the summary
+// Speechmatics made in the job, if any, with the decisions and action items
+// of the configured summarizer. It returns nil when there is neither.
+func (h *BatchTranscribeHandler) meetingSummary(ctx context.Context, jobID string, transcript *speechmatics.TranscriptResponse, s *sessions.Session) *sessions.MeetingSummary {
+	cfg := h.cfg.Current().Summary
+	var local *sessions.MeetingSummary
+	if h.summarizer != nil && (cfg.OnEnd || transcript.Summary != nil) {
+		ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+		var err error
+		if local, err = h.summarizer.Summarize(ctx, s); err != nil {
+			h.logger.ErrorContext(ctx, "Failed to summarize batch transcript", "job_id", jobID, "error", err)
+		}
+	}
+	if transcript.Summary == nil {
+		return local
+	}
+
+	sum := &sessions.MeetingSummary{
+		Summary:     transcript.Summary.Content,
+		Decisions:   []string{},
+		ActionItems: []sessions.ActionItem{},
+		Summarizer:  "speechmatics",
+		CreatedAt:   time.Now().UTC(),
+	}
+	if local != nil {
+		sum.Decisions = local.Decisions
+		sum.ActionItems = local.ActionItems
+	}
+	return sum
 }
 
 // HandleSubmit handles the submission of audio for batch transcription
@@ -52,8 +452,9 @@ func (h *BatchTranscribeHandler) HandleSubmit(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Parse multipart form (max 100MB)
-	if err := r.ParseMultipartForm(100 << 20); err != nil {
+	// Parse multipart form (size limited by configuration)
+	cfg := h.cfg.Current()
+	if err := r.ParseMultipartForm(cfg.Batch.MaxUploadBytes); err != nil {
 		http.Error(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -83,36 +484,25 @@ func (h *BatchTranscribeHandler) HandleSubmit(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	// Set defaults
-	if reqConfig.Language == "" {
-		reqConfig.Language = "en"
-	}
-	if reqConfig.Diarization == "" {
-		reqConfig.Diarization = "speaker"
-	}
-	if reqConfig.OperatingPoint == "" {
-		reqConfig.OperatingPoint = "enhanced"
-	}
-
-	// Create job config
-	jobConfig := speechmatics.JobConfig{
-		Type: "transcription",
-		TranscriptionConfig: speechmatics.TranscriptionConfig{
-			Language:       reqConfig.Language,
-			Diarization:    reqConfig.Diarization,
-			EnablePartials: true,
-			OperatingPoint: reqConfig.OperatingPoint,
-			MaxDelay:       reqConfig.MaxDelay,
-		},
+	jobConfig, err := h.jobConfig(r.Context(), &reqConfig)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Submit job
-	jobResp, err := h.batchClient.SubmitJob(audioData, handler.Filename, &jobConfig)
+	jobResp, err := h.batchClient.SubmitJob(r.Context(), audioData, handler.Filename, jobConfig)
 	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to submit batch job", "error", err)
 		http.Error(w, "Failed to submit job: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if jobConfig.TranslationConfig == nil && len(reqConfig.TargetLanguages) > 0 {
+		h.targets.Store(jobResp.ID, reqConfig.TargetLanguages)
+	}
+	h.watchJob(jobResp.ID)
+
 	// Return job info
 	resp := BatchTranscribeResponse{
 		JobID:  jobResp.ID,
@@ -139,7 +529,7 @@ func (h *BatchTranscribeHandler) HandleStatus(w http.ResponseWriter, r *http.Req
 	}
 
 	// Get job status
-	status, err := h.batchClient.GetJobStatus(jobID)
+	status, err := h.batchClient.GetJobStatus(r.Context(), jobID)
 	if err != nil {
 		http.Error(w, "Failed to get job status: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -152,11 +542,12 @@ func (h *BatchTranscribeHandler) HandleStatus(w http.ResponseWriter, r *http.Req
 
 	// If job is done, get the transcript
 	if status.Status == "done" {
-		transcript, err := h.batchClient.GetTranscript(jobID, "json-v2")
+		transcript, err := h.batchClient.GetTranscript(r.Context(), jobID, "json-v2")
 		if err != nil {
 			resp.Error = "Failed to get transcript: " + err.Error()
 		} else {
 			resp.Transcript = transcript
+			resp.Translations = h.finishTranscript(r.Context(), jobID, transcript)
 		}
 	}
 
@@ -173,8 +564,9 @@ func (h *BatchTranscribeHandler) HandleTranscribeAndWait(w http.ResponseWriter,
 		return
 	}
 
-	// Parse multipart form (max 100MB)
-	if err := r.ParseMultipartForm(100 << 20); err != nil {
+	// Parse multipart form (size limited by configuration)
+	cfg := h.cfg.Current()
+	if err := r.ParseMultipartForm(cfg.Batch.MaxUploadBytes); err != nil {
 		http.Error(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -204,38 +596,27 @@ func (h *BatchTranscribeHandler) HandleTranscribeAndWait(w http.ResponseWriter,
 		}
 	}
 
-	// Set defaults
-	if reqConfig.Language == "" {
-		reqConfig.Language = "en"
-	}
-	if reqConfig.Diarization == "" {
-		reqConfig.Diarization = "speaker"
-	}
-	if reqConfig.OperatingPoint == "" {
-		reqConfig.OperatingPoint = "enhanced"
-	}
-
-	// Create job config
-	jobConfig := speechmatics.JobConfig{
-		Type: "transcription",
-		TranscriptionConfig: speechmatics.TranscriptionConfig{
-			Language:       reqConfig.Language,
-			Diarization:    reqConfig.Diarization,
-			EnablePartials: true,
-			OperatingPoint: reqConfig.OperatingPoint,
-			MaxDelay:       reqConfig.MaxDelay,
-		},
+	jobConfig, err := h.jobConfig(r.Context(), &reqConfig)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Submit job
-	jobResp, err := h.batchClient.SubmitJob(audioData, handler.Filename, &jobConfig)
+	jobResp, err := h.batchClient.SubmitJob(r.Context(), audioData, handler.Filename, jobConfig)
 	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to submit batch job", "error", err)
 		http.Error(w, "Failed to submit job: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Wait for completion (max 10 minutes)
-	if err := h.batchClient.WaitForCompletion(jobResp.ID, 10*time.Minute); err != nil {
+	if jobConfig.TranslationConfig == nil && len(reqConfig.TargetLanguages) > 0 {
+		h.targets.Store(jobResp.ID, reqConfig.TargetLanguages)
+	}
+	h.watchJob(jobResp.ID)
+
+	// Wait for completion
+	if err := h.batchClient.WaitForCompletion(r.Context(), jobResp.ID, cfg.Batch.WaitTimeout); err != nil {
 		resp := BatchTranscribeResponse{
 			JobID:  jobResp.ID,
 			Status: "error",
@@ -243,13 +624,13 @@ func (h *BatchTranscribeHandler) HandleTranscribeAndWait(w http.ResponseWriter,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Printf("Failed to encode response: %v", err)
+			h.logger.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		}
 		return
 	}
 
 	// Get transcript
-	transcript, err := h.batchClient.GetTranscript(jobResp.ID, "json-v2")
+	transcript, err := h.batchClient.GetTranscript(r.Context(), jobResp.ID, "json-v2")
 	if err != nil {
 		resp := BatchTranscribeResponse{
 			JobID:  jobResp.ID,
@@ -258,16 +639,19 @@ func (h *BatchTranscribeHandler) HandleTranscribeAndWait(w http.ResponseWriter,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Printf("Failed to encode response: %v", err)
+			h.logger.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		}
 		return
 	}
 
+	translations := h.finishTranscript(r.Context(), jobResp.ID, transcript)
+
 	// Return success response
 	resp := BatchTranscribeResponse{
-		JobID:      jobResp.ID,
-		Status:     "done",
-		Transcript: transcript,
+		JobID:        jobResp.ID,
+		Status:       "done",
+		Transcript:   transcript,
+		Translations: translations,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -275,3 +659,93 @@ func (h *BatchTranscribeHandler) HandleTranscribeAndWait(w http.ResponseWriter,
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+// HandleJobEvents streams the status of a batch job as Server-Sent Events
+// until the job finishes, so that callers do not have to poll HandleStatus.
+// A status event is sent whenever the status changes, with the status as the
+// event ID: a client that reconnects with Last-Event-ID is only sent the
+// status again if it changed. The stream ends with a "done" event carrying
+// the transcript or a "failed" event; EventSource clients should close on
+// either, as they otherwise reconnect.
+func (h *BatchTranscribeHandler) HandleJobEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID := r.PathValue("id")
+	status, err := h.batchClient.GetJobStatus(ctx, jobID)
+	if err != nil {
+		http.Error(w, "Failed to get job status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stream, err := newSSEWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cfg := h.cfg.Current().Batch
+	deadline := time.Now().Add(cfg.WaitTimeout)
+	last := lastEventID(r)
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if err := stream.comment("keep-alive"); err != nil {
+				return
+			}
+			continue
+		case <-poll.C:
+		}
+
+		if status == nil {
+			if status, err = h.batchClient.GetJobStatus(ctx, jobID); err != nil {
+				h.logger.WarnContext(ctx, "Failed to get job status", "job_id", jobID, "error", err)
+			}
+		}
+		if status != nil {
+			finished := speechmatics.IsFinalStatus(status.Status)
+			if status.Status != last {
+				last = status.Status
+				if err := h.sendJobStatus(ctx, stream, jobID, status.Status); err != nil {
+					return
+				}
+			}
+			// A client resuming after the final event is not sent it again
+			if finished {
+				return
+			}
+		}
+
+		if time.Now().After(deadline) {
+			_ = stream.event("", "failed", BatchTranscribeResponse{JobID: jobID, Status: "error", Error: "timeout waiting for job completion"})
+			return
+		}
+		status = nil
+		poll.Reset(cfg.PollInterval)
+	}
+}
+
+// sendJobStatus sends a status event, or the final done or failed event
+func (h *BatchTranscribeHandler) sendJobStatus(ctx context.Context, stream *sseWriter, jobID, status string) error {
+	resp := BatchTranscribeResponse{JobID: jobID, Status: status}
+	typ := "status"
+	switch {
+	case status == "done":
+		typ = "done"
+		transcript, err := h.batchClient.GetTranscript(ctx, jobID, "json-v2")
+		if err != nil {
+			resp.Error = "Failed to get transcript: " + err.Error()
+		} else {
+			resp.Transcript = transcript
+			resp.Translations = h.finishTranscript(ctx, jobID, transcript)
+		}
+	case speechmatics.IsFinalStatus(status):
+		typ = "failed"
+	}
+	return stream.event(status, typ, resp)
+}
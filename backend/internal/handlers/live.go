@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/live"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/gorilla/websocket"
+)
+
+// maxLiveMessageBytes limits the size of a message from a producer or
+// subscriber; larger messages close the connection
+const maxLiveMessageBytes = 64 << 10
+
+// LiveCreateRequest starts a broadcast. Language is the language spoken,
+// which on-demand translation translates from.
+type LiveCreateRequest struct {
+	Title    string `json:"title"`
+	Language string `json:"language"`
+}
+
+// LiveCreateResponse returns the join code and the producer key. The key is
+// only returned here; subscribers only need the code.
+type LiveCreateResponse struct {
+	live.Info
+	Key string `json:"key"`
+}
+
+// LiveMessage is a message from the producer. Type is one of the text event
+// types, "speakers" to rename speakers, "end_of_utterance" when the engine
+// detected the end of an utterance or "end" to end the broadcast. Utterance
+// events are assembled by the server and cannot be published.
+type LiveMessage struct {
+	Type      string            `json:"type"`
+	Language  string            `json:"language"`
+	Speaker   string            `json:"speaker"`
+	Text      string            `json:"text"`
+	StartTime float64           `json:"start_time"`
+	EndTime   float64           `json:"end_time"`
+	Speakers  map[string]string `json:"speakers"`
+}
+
+// SubscriberMessage is a message from a WebSocket subscriber. Type
+// "language" switches the language filter, e.g. "original,ja"; languages
+// the producer does not publish are translated on demand when a translation
+// backend is configured.
+type SubscriberMessage struct {
+	Type     string `json:"type"`
+	Language string `json:"language"`
+}
+
+// LiveHandler serves live broadcasts: one producer publishes transcript
+// events over a WebSocket and any number of subscribers follow them over a
+// WebSocket or Server-Sent Events
+type LiveHandler struct {
+	hub    *live.Hub
+	ws     *WebSocketHandler
+	logger *slog.Logger
+}
+
+// NewLiveHandler creates a new live broadcast handler. WebSocket connections
+// are tracked by ws so that they are drained on shutdown.
+func NewLiveHandler(hub *live.Hub, ws *WebSocketHandler) *LiveHandler {
+	return &LiveHandler{hub: hub, ws: ws, logger: logging.For("live")}
+}
+
+// HandleCreate starts a broadcast and returns its join code and producer key
+func (h *LiveHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req LiveCreateRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+	b, err := h.hub.Create(r.Context(), req.Title, req.Language)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, LiveCreateResponse{Info: b.Info(), Key: b.Key()})
+}
+
+// HandleGet describes a broadcast
+func (h *LiveHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	b, err := h.hub.Get(r.PathValue("code"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b.Info())
+}
+
+// HandlePublish serves the producer WebSocket. The producer authenticates with
+// the key returned on creation (?key=) and sends LiveMessages.
+func (h *LiveHandler) HandlePublish(w http.ResponseWriter, r *http.Request) {
+	b, err := h.hub.Get(r.PathValue("code"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if !b.Authorize(r.URL.Query().Get("key")) {
+		http.Error(w, "Invalid producer key", http.StatusForbidden)
+		return
+	}
+
+	session, ok := h.upgrade(w, r)
+	if !ok {
+		return
+	}
+	defer h.ws.unregister(session)
+	defer session.conn.Close()
+
+	ctx := r.Context()
+	h.logger.InfoContext(ctx, "Producer connected", "live_code", b.Code)
+	defer h.logger.InfoContext(ctx, "Producer disconnected", "live_code", b.Code)
+
+	for {
+		_, data, err := session.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg LiveMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			_ = session.writeJSON(map[string]string{"type": "error", "error": "invalid message: " + err.Error()})
+			continue
+		}
+
+		switch msg.Type {
+		case live.EventEnd:
+			b.End()
+			_ = session.close(websocket.CloseNormalClosure, "broadcast ended")
+			return
+		case live.EventSpeakers:
+			err = b.RenameSpeakers(msg.Speakers)
+		case "end_of_utterance":
+			err = b.EndOfUtterance()
+		case live.EventPartial, live.EventFinal, live.EventPartialTranslation, live.EventTranslation:
+			_, err = b.Publish(live.Event{
+				Type:      msg.Type,
+				Language:  msg.Language,
+				Speaker:   msg.Speaker,
+				Text:      msg.Text,
+				StartTime: msg.StartTime,
+				EndTime:   msg.EndTime,
+			})
+		default:
+			err = errors.New("unknown message type " + strconv.Quote(msg.Type))
+		}
+
+		if errors.Is(err, live.ErrEnded) {
+			_ = session.close(websocket.CloseNormalClosure, "broadcast ended")
+			return
+		}
+		if err != nil {
+			_ = session.writeJSON(map[string]string{"type": "error", "error": err.Error()})
+		}
+	}
+}
+
+// HandleSubscribe serves a read-only subscriber WebSocket. ?language= filters
+// the events (e.g. "original,ja") and can be changed with a SubscriberMessage.
+// ?partials=diff sends partials as diffs against the previous partial.
+func (h *LiveHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	b, err := h.hub.Get(r.PathValue("code"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	session, ok := h.upgrade(w, r)
+	if !ok {
+		return
+	}
+	defer h.ws.unregister(session)
+	defer session.conn.Close()
+
+	sub, replay := b.Subscribe(live.ParseFilter(r.URL.Query().Get("language")), 0)
+	defer sub.Close()
+	differ := partialDiffer(r)
+	metrics.LiveSubscribers.WithLabelValues("websocket").Inc()
+	defer metrics.LiveSubscribers.WithLabelValues("websocket").Dec()
+
+	// Read subscriber messages; a read error means the client went away
+	go func() {
+		defer sub.Close()
+		for {
+			_, data, err := session.conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg SubscriberMessage
+			if json.Unmarshal(data, &msg) == nil && msg.Type == "language" {
+				sub.SetFilter(live.ParseFilter(msg.Language))
+			}
+		}
+	}()
+
+	for _, ev := range replay {
+		if err := session.writeJSON(ev); err != nil {
+			return
+		}
+	}
+	for ev := range sub.Events() {
+		if err := session.writeJSON(differ.Apply(ev)); err != nil {
+			return
+		}
+	}
+
+	switch err := sub.Err(); {
+	case errors.Is(err, live.ErrSlow):
+		_ = session.close(websocket.CloseTryAgainLater, "too slow, reconnect")
+	case errors.Is(err, live.ErrShutdown):
+		_ = session.close(websocket.CloseServiceRestart, RestartCloseReason)
+	default:
+		_ = session.close(websocket.CloseNormalClosure, "broadcast ended")
+	}
+}
+
+// HandleEvents streams the events of a broadcast as Server-Sent Events, with
+// the same ?language= and ?partials= options as HandleSubscribe
+func (h *LiveHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	b, err := h.hub.Get(r.PathValue("code"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.streamEvents(w, r, b)
+}
+
+// HandleSessionEvents streams the events of the broadcast that is storing
+// the session, for consumers that know the session rather than the join code
+func (h *LiveHandler) HandleSessionEvents(w http.ResponseWriter, r *http.Request) {
+	b, err := h.hub.BySession(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Session is not live", http.StatusNotFound)
+		return
+	}
+	h.streamEvents(w, r, b)
+}
+
+// streamEvents sends the events of a broadcast as Server-Sent Events. Event
+// IDs are the broadcast event IDs, so a client reconnecting with
+// Last-Event-ID receives the stored events it missed; partials are not
+// stored and are not replayed.
+func (h *LiveHandler) streamEvents(w http.ResponseWriter, r *http.Request, b *live.Broadcast) {
+	after, err := lastEventNumber(r)
+	if err != nil {
+		http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	stream, err := newSSEWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sub, replay := b.Subscribe(live.ParseFilter(r.URL.Query().Get("language")), after)
+	defer sub.Close()
+	differ := partialDiffer(r)
+	metrics.LiveSubscribers.WithLabelValues("sse").Inc()
+	defer metrics.LiveSubscribers.WithLabelValues("sse").Dec()
+
+	for _, ev := range replay {
+		if err := stream.event(strconv.FormatUint(ev.ID, 10), ev.Type, ev); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			ev = differ.Apply(ev)
+			if err := stream.event(strconv.FormatUint(ev.ID, 10), ev.Type, ev); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := stream.comment("keep-alive"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// partialDiffer returns a differ when the subscriber asked for partial
+// diffs with ?partials=diff, and nil otherwise
+func partialDiffer(r *http.Request) *live.PartialDiffer {
+	if r.URL.Query().Get("partials") == "diff" {
+		return live.NewPartialDiffer()
+	}
+	return nil
+}
+
+// upgrade upgrades the request to a WebSocket tracked by the WebSocket
+// handler. It returns false if the upgrade failed or the server is draining.
+func (h *LiveHandler) upgrade(w http.ResponseWriter, r *http.Request) (*wsSession, bool) {
+	conn, err := h.ws.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Failed to upgrade connection", "error", err)
+		return nil, false
+	}
+	conn.SetReadLimit(maxLiveMessageBytes)
+	session := &wsSession{conn: conn}
+	if !h.ws.register(session) {
+		_ = session.close(websocket.CloseServiceRestart, RestartCloseReason)
+		conn.Close()
+		return nil, false
+	}
+	return session, true
+}
+
+func (h *LiveHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, live.ErrNotFound):
+		http.Error(w, "Broadcast not found", http.StatusNotFound)
+	default:
+		h.logger.ErrorContext(r.Context(), "Live request failed", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
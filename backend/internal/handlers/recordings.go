@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/recording"
+)
+
+// audioContentTypes maps recording formats to MIME types
+var audioContentTypes = map[string]string{
+	"wav":  "audio/wav",
+	"flac": "audio/flac",
+}
+
+// RecordingsHandler serves the archived realtime session recordings
+type RecordingsHandler struct {
+	store  *recording.Store
+	logger *slog.Logger
+}
+
+// NewRecordingsHandler creates a new recordings handler
+func NewRecordingsHandler(store *recording.Store) *RecordingsHandler {
+	return &RecordingsHandler{store: store, logger: logging.For("web")}
+}
+
+// HandleList lists recordings, newest first
+func (h *RecordingsHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	recordings, err := h.store.List()
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to list recordings", "error", err)
+		http.Error(w, "Failed to list recordings", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recordings": recordings})
+}
+
+// HandleGet returns a recording's metadata and transcript
+func (h *RecordingsHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	rec, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// HandleDownload streams the recording's audio file
+func (h *RecordingsHandler) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	rec, f, err := h.store.Open(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", audioContentTypes[rec.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rec.AudioFile))
+	http.ServeContent(w, r, rec.AudioFile, info.ModTime(), f)
+}
+
+// HandleDelete deletes a recording and its audio
+func (h *RecordingsHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.store.Delete(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Recording deleted", "recording_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RecordingsHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, recording.ErrNotFound) {
+		http.Error(w, "Recording not found", http.StatusNotFound)
+		return
+	}
+	h.logger.ErrorContext(r.Context(), "Recording request failed", "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/search"
+)
+
+// SearchHandler serves full-text search over stored sessions
+type SearchHandler struct {
+	index  *search.Index
+	logger *slog.Logger
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(index *search.Index) *SearchHandler {
+	return &SearchHandler{index: index, logger: logging.For("web")}
+}
+
+// HandleSearch searches transcripts and translations.
+// Parameters: q (required), speaker, language, from, to (RFC 3339 or
+// YYYY-MM-DD, to is inclusive) and limit.
+func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := search.Query{
+		Text:     strings.TrimSpace(params.Get("q")),
+		Speaker:  params.Get("speaker"),
+		Language: params.Get("language"),
+	}
+	if q.Text == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if q.From, err = parseDate(params.Get("from"), false); err != nil {
+		http.Error(w, "Invalid from parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.To, err = parseDate(params.Get("to"), true); err != nil {
+		http.Error(w, "Invalid to parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hits, err := h.index.Search(q)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Search failed", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"hits": hits})
+}
+
+// parseDate parses an RFC 3339 timestamp or a date. A date used as the end of
+// a range covers the whole day.
+func parseDate(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/summarize"
+)
+
+// maxSessionBodyBytes limits imported sessions and update requests
+const maxSessionBodyBytes = 32 << 20
+
+// SessionUpdateRequest renames or retags a session. Omitted fields are
+// unchanged. Speakers names speaker labels and is merged into the existing
+// names; an empty name removes the name of a label.
+type SessionUpdateRequest struct {
+	Title    *string           `json:"title"`
+	Tags     *[]string         `json:"tags"`
+	Speakers map[string]string `json:"speakers"`
+}
+
+// SegmentEditRequest changes the text and/or speaker of a segment
+type SegmentEditRequest struct {
+	Author  string  `json:"author"`
+	Text    *string `json:"text"`
+	Speaker *string `json:"speaker"`
+}
+
+// SegmentSplitRequest splits a segment at a character offset into its text.
+// Time optionally sets the split time in seconds.
+type SegmentSplitRequest struct {
+	Author string   `json:"author"`
+	Offset int      `json:"offset"`
+	Time   *float64 `json:"time"`
+}
+
+// SegmentMergeRequest merges consecutive segments
+type SegmentMergeRequest struct {
+	Author     string `json:"author"`
+	SegmentIDs []int  `json:"segment_ids"`
+}
+
+// RevertRequest restores the transcript to a revision
+type RevertRequest struct {
+	Author string `json:"author"`
+}
+
+// RevisionResponse is a revision with its segment changes
+type RevisionResponse struct {
+	sessions.Revision
+	Changes []sessions.Change `json:"changes"`
+}
+
+// SessionsHandler serves the stored sessions API
+type SessionsHandler struct {
+	store      *sessions.Store
+	summarizer summarize.Summarizer
+	logger     *slog.Logger
+}
+
+// NewSessionsHandler creates a new sessions handler. Sessions can be
+// summarized on request when summarizer is not nil.
+func NewSessionsHandler(store *sessions.Store, summarizer summarize.Summarizer) *SessionsHandler {
+	return &SessionsHandler{store: store, summarizer: summarizer, logger: logging.For("web")}
+}
+
+// HandleList lists stored sessions, most recent first, optionally filtered by ?tag=
+func (h *SessionsHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.List(r.URL.Query().Get("tag"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": list})
+}
+
+// HandleGet returns a session with its segments and translations. The
+// revision history is served separately by HandleRevisions.
+func (h *SessionsHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	s, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	s.Revisions = nil
+	s.MeetingSummary = s.PresentSummary()
+	writeJSON(w, http.StatusOK, s)
+}
+
+// HandleSummary returns the meeting summary of a session with the current
+// speaker names as action item owners
+func (h *SessionsHandler) HandleSummary(w http.ResponseWriter, r *http.Request) {
+	s, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	sum := s.PresentSummary()
+	if sum == nil {
+		http.Error(w, "Session has not been summarized", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, sum)
+}
+
+// HandleSummarize summarizes a session, replacing any previous summary
+func (h *SessionsHandler) HandleSummarize(w http.ResponseWriter, r *http.Request) {
+	if h.summarizer == nil {
+		http.Error(w, "Summaries are disabled", http.StatusBadRequest)
+		return
+	}
+	id := r.PathValue("id")
+	sum, err := summarize.Session(r.Context(), h.summarizer, h.store, id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Session summarized", "stored_session_id", id, "decisions", len(sum.Decisions), "action_items", len(sum.ActionItems))
+	writeJSON(w, http.StatusOK, sum)
+}
+
+// HandleExport renders the corrected transcript, or a translation with
+// ?language=, as txt, srt or vtt (?format=, default txt)
+func (h *SessionsHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = sessions.FormatText
+	}
+	contentType, ok := sessions.ExportContentTypes[format]
+	if !ok {
+		http.Error(w, "Unsupported format, use txt, srt or vtt", http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	language := r.URL.Query().Get("language")
+	data, err := s.Export(format, language)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	filename := s.ID
+	if language != "" {
+		filename += "." + language
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	_, _ = w.Write(data)
+}
+
+// HandleEditSegment changes the text or speaker of a segment
+func (h *SessionsHandler) HandleEditSegment(w http.ResponseWriter, r *http.Request) {
+	var req SegmentEditRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	segmentID, ok := pathInt(w, r, "segment")
+	if !ok {
+		return
+	}
+	h.edit(w, r, func(s *sessions.Session) (*sessions.Revision, error) {
+		return s.EditSegment(req.Author, segmentID, req.Text, req.Speaker)
+	})
+}
+
+// HandleSplitSegment splits a segment in two
+func (h *SessionsHandler) HandleSplitSegment(w http.ResponseWriter, r *http.Request) {
+	var req SegmentSplitRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	segmentID, ok := pathInt(w, r, "segment")
+	if !ok {
+		return
+	}
+	h.edit(w, r, func(s *sessions.Session) (*sessions.Revision, error) {
+		return s.SplitSegment(req.Author, segmentID, req.Offset, req.Time)
+	})
+}
+
+// HandleMergeSegments merges consecutive segments
+func (h *SessionsHandler) HandleMergeSegments(w http.ResponseWriter, r *http.Request) {
+	var req SegmentMergeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	h.edit(w, r, func(s *sessions.Session) (*sessions.Revision, error) {
+		return s.MergeSegments(req.Author, req.SegmentIDs)
+	})
+}
+
+// HandleRevert restores the transcript to its state after a revision
+func (h *SessionsHandler) HandleRevert(w http.ResponseWriter, r *http.Request) {
+	var req RevertRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	rev, ok := pathInt(w, r, "rev")
+	if !ok {
+		return
+	}
+	h.edit(w, r, func(s *sessions.Session) (*sessions.Revision, error) {
+		return s.Revert(req.Author, rev)
+	})
+}
+
+// edit applies a transcript edit and responds with the new revision
+func (h *SessionsHandler) edit(w http.ResponseWriter, r *http.Request, fn func(*sessions.Session) (*sessions.Revision, error)) {
+	var rev sessions.Revision
+	_, err := h.store.Update(r.PathValue("id"), func(s *sessions.Session) error {
+		applied, err := fn(s)
+		if err != nil {
+			return err
+		}
+		rev = *applied
+		return nil
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Transcript edited", "stored_session_id", r.PathValue("id"), "revision", rev.ID, "operation", rev.Operation, "author", rev.Author)
+	writeJSON(w, http.StatusOK, revisionResponse(rev))
+}
+
+// HandleRevisions lists the revisions of a session, oldest first
+func (h *SessionsHandler) HandleRevisions(w http.ResponseWriter, r *http.Request) {
+	s, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	revisions := make([]RevisionResponse, 0, len(s.Revisions))
+	for _, rev := range s.Revisions {
+		revisions = append(revisions, revisionResponse(rev))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revisions": revisions})
+}
+
+// HandleDiff compares the transcript between two revisions. from defaults to
+// 0 (the unedited transcript) and to defaults to the latest revision.
+func (h *SessionsHandler) HandleDiff(w http.ResponseWriter, r *http.Request) {
+	s, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	from, to := 0, len(s.Revisions)
+	for name, dst := range map[string]*int{"from": &from, "to": &to} {
+		if v := r.URL.Query().Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid "+name+" parameter", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	before, err := s.StateAt(from)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	after, err := s.StateAt(to)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"changes": sessions.Diff(before, after),
+	})
+}
+
+func revisionResponse(rev sessions.Revision) RevisionResponse {
+	return RevisionResponse{Revision: rev, Changes: sessions.Diff(rev.Before, rev.After)}
+}
+
+// HandleCreate imports a session recorded elsewhere, for example in the browser
+func (h *SessionsHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var s sessions.Session
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSessionBodyBytes)).Decode(&s); err != nil {
+		http.Error(w, "Invalid session: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.ID != "" && !sessions.ValidID.MatchString(s.ID) {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+	s.PrepareImport()
+	s.Tags = cleanTags(s.Tags)
+
+	if err := h.store.Create(&s); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Session imported", "stored_session_id", s.ID, "segments", len(s.Segments))
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// HandleUpdate renames or retags a session and names its speakers
+func (h *SessionsHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	var req SessionUpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		http.Error(w, "Title must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.store.Update(r.PathValue("id"), func(s *sessions.Session) error {
+		if req.Title != nil {
+			s.Title = strings.TrimSpace(*req.Title)
+		}
+		if req.Tags != nil {
+			s.Tags = cleanTags(*req.Tags)
+		}
+		return s.RenameSpeakers(req.Speakers)
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Summary())
+}
+
+// HandleDelete deletes a session
+func (h *SessionsHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.store.Delete(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Session deleted", "stored_session_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SessionsHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, sessions.ErrNotFound):
+		http.Error(w, "Session not found", http.StatusNotFound)
+	case errors.Is(err, sessions.ErrExists):
+		http.Error(w, "Session already exists", http.StatusConflict)
+	case errors.Is(err, sessions.ErrInvalidEdit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.ErrorContext(r.Context(), "Session request failed", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// decodeJSON decodes a request body and responds with 400 on failure
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSessionBodyBytes)).Decode(v); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// pathInt parses an integer path parameter and responds with 400 on failure
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		http.Error(w, "Invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+// cleanTags trims tags and drops empty and duplicate entries
+func cleanTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		cleaned = append(cleaned, t)
+	}
+	return cleaned
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/speakers"
+)
+
+// EnrollRequest enrolls a speaker, either with identifiers returned by the
+// engine or with the identifiers stored for a speaker of a session
+type EnrollRequest struct {
+	Name        string   `json:"name"`
+	Identifiers []string `json:"identifiers"`
+	SessionID   string   `json:"session_id"`
+	// Speaker is the label (such as "S1") or assigned name of the speaker
+	// in the session
+	Speaker string `json:"speaker"`
+}
+
+// SpeakersHandler serves the speaker profiles API
+type SpeakersHandler struct {
+	registry *speakers.Registry
+	sessions *sessions.Store
+	logger   *slog.Logger
+}
+
+// NewSpeakersHandler creates a new speaker profiles handler. Enrolling from a
+// session requires store; it may be nil when session storage is disabled.
+func NewSpeakersHandler(registry *speakers.Registry, store *sessions.Store) *SpeakersHandler {
+	return &SpeakersHandler{registry: registry, sessions: store, logger: logging.For("web")}
+}
+
+// HandleList lists the enrolled speakers
+func (h *SpeakersHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.registry.List()
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"speakers": profiles})
+}
+
+// HandleEnroll creates a profile or adds identifiers to an existing profile
+// with the same name. When enrolling from a session, the speaker is also
+// named in that session.
+func (h *SpeakersHandler) HandleEnroll(w http.ResponseWriter, r *http.Request) {
+	var req EnrollRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	identifiers := req.Identifiers
+	label := ""
+	if req.SessionID != "" {
+		if h.sessions == nil {
+			http.Error(w, "Session storage is disabled", http.StatusBadRequest)
+			return
+		}
+		s, err := h.sessions.Get(req.SessionID)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		label = sessionSpeakerLabel(s, req.Speaker)
+		if len(s.SpeakerIdentifiers[label]) == 0 {
+			http.Error(w, "No speaker identifiers stored for this speaker", http.StatusBadRequest)
+			return
+		}
+		identifiers = append(identifiers, s.SpeakerIdentifiers[label]...)
+	}
+
+	profile, created, err := h.registry.Enroll(req.Name, identifiers)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if label != "" && label != profile.Name {
+		if _, err := h.sessions.Update(req.SessionID, func(s *sessions.Session) error {
+			return s.RenameSpeakers(map[string]string{label: profile.Name})
+		}); err != nil {
+			h.logger.WarnContext(r.Context(), "Failed to name enrolled speaker in session", "stored_session_id", req.SessionID, "error", err)
+		}
+	}
+
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	writeJSON(w, code, profile)
+}
+
+// HandleDelete deletes a profile. Sessions that already recognized the
+// speaker keep the name.
+func (h *SpeakersHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.registry.Delete(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Speaker profile deleted", "profile_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SpeakersHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, speakers.ErrNotFound):
+		http.Error(w, "Speaker profile not found", http.StatusNotFound)
+	case errors.Is(err, sessions.ErrNotFound):
+		http.Error(w, "Session not found", http.StatusNotFound)
+	case errors.Is(err, speakers.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.ErrorContext(r.Context(), "Speaker profile request failed", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// sessionSpeakerLabel resolves a speaker given by label or by the name
+// assigned in the session
+func sessionSpeakerLabel(s *sessions.Session, speaker string) string {
+	if _, ok := s.SpeakerIdentifiers[speaker]; ok {
+		return speaker
+	}
+	for label := range s.SpeakerIdentifiers {
+		if s.SpeakerName(label) == speaker {
+			return label
+		}
+	}
+	return speaker
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseKeepAlive is how often an idle event stream sends a comment, so that
+// proxies do not close it
+const sseKeepAlive = 15 * time.Second
+
+// sseWriter writes a Server-Sent Events stream
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newSSEWriter starts an event stream. The server write timeout is lifted for
+// the request because streams stay open for as long as the client listens.
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("event streams are not supported: %w", err)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &sseWriter{w: w, rc: rc}
+	return s, rc.Flush()
+}
+
+// event sends one event with the given id and type and v as JSON data
+func (s *sseWriter) event(id, typ string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		fmt.Fprintf(s.w, "id: %s\n", id)
+	}
+	if typ != "" {
+		fmt.Fprintf(s.w, "event: %s\n", typ)
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// comment sends a comment line, which clients ignore
+func (s *sseWriter) comment(text string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// lastEventID returns the ID of the last event a client received: the
+// Last-Event-ID header that EventSource sends when it reconnects, or the
+// last_event_id query parameter for clients that cannot set headers
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+// lastEventNumber returns the numeric event ID a client resumes after, or 0
+// when the client starts from the beginning
+func lastEventNumber(r *http.Request) (uint64, error) {
+	id := lastEventID(r)
+	if id == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(id, 10, 64)
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/dreamtrans/backend/internal/auth"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
 )
 
 type TokenResponse struct {
@@ -14,34 +16,27 @@ type TokenResponse struct {
 
 type TokenHandler struct {
 	tokenGen *auth.TokenGenerator
+	logger   *slog.Logger
 }
 
-func NewTokenHandler() (*TokenHandler, error) {
-	tokenGen, err := auth.NewTokenGenerator()
+func NewTokenHandler(cfg *config.Manager) (*TokenHandler, error) {
+	tokenGen, err := auth.NewTokenGenerator(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &TokenHandler{tokenGen: tokenGen}, nil
+	return &TokenHandler{tokenGen: tokenGen, logger: logging.For("web")}, nil
 }
 
 func (h *TokenHandler) HandleTokenRequest(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
+	// CORS headers and preflight requests are handled by the origin policy middleware
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	token, err := h.tokenGen.GenerateToken()
+	token, err := h.tokenGen.GenerateToken(r.Context())
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
+		h.logger.ErrorContext(r.Context(), "Failed to generate token", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -51,6 +46,6 @@ func (h *TokenHandler) HandleTokenRequest(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		h.logger.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
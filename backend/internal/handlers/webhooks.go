@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/webhooks"
+)
+
+// WebhooksHandler serves the webhook delivery log and dead letter queue
+type WebhooksHandler struct {
+	dispatcher *webhooks.Dispatcher
+	logger     *slog.Logger
+}
+
+// NewWebhooksHandler creates a new webhooks handler
+func NewWebhooksHandler(dispatcher *webhooks.Dispatcher) *WebhooksHandler {
+	return &WebhooksHandler{dispatcher: dispatcher, logger: logging.For("web")}
+}
+
+// HandleDeliveries lists recent delivery attempts, most recent first,
+// optionally filtered by ?sink= and ?event=
+func (h *WebhooksHandler) HandleDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": h.dispatcher.Deliveries(q.Get("sink"), q.Get("event")),
+	})
+}
+
+// HandleDeadLetters lists the events that could not be delivered, optionally
+// filtered by ?sink=
+func (h *WebhooksHandler) HandleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.dispatcher.DeadLetters(r.URL.Query().Get("sink"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"dead_letters": letters})
+}
+
+// HandleRedeliver queues a dead letter for delivery again
+func (h *WebhooksHandler) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.dispatcher.Redeliver(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Dead letter queued for redelivery", "delivery_id", id)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleDeleteDeadLetter discards a dead letter
+func (h *WebhooksHandler) HandleDeleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.dispatcher.DeleteDeadLetter(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "Dead letter deleted", "delivery_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhooksHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, webhooks.ErrNotFound) {
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+	h.logger.ErrorContext(r.Context(), "Webhook request failed", "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -1,43 +1,172 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/origin"
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// 警告：仅用于开发！生产环境需要更严格的配置
-		return true
-	},
+// RestartCloseReason is sent to WebSocket clients when the server shuts down
+const RestartCloseReason = "server restarting, reconnect"
+
+// wsWriteTimeout bounds a single write to a WebSocket client
+const wsWriteTimeout = 10 * time.Second
+
+// wsSession is a single open WebSocket connection
+type wsSession struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// writeJSON sends v as a JSON text message
+func (s *wsSession) writeJSON(v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(v)
+}
+
+// close sends a close frame with the given code and reason
+func (s *wsSession) close(code int, reason string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	msg := websocket.FormatCloseMessage(code, reason)
+	return s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
+
+// WebSocketHandler serves WebSocket sessions and tracks them so that they can
+// be closed cleanly when the server shuts down
+type WebSocketHandler struct {
+	logger   *slog.Logger
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	sessions map[*wsSession]struct{}
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// NewWebSocketHandler creates a new WebSocket handler that only accepts
+// upgrades from origins allowed by the policy
+func NewWebSocketHandler(policy *origin.Policy) *WebSocketHandler {
+	return &WebSocketHandler{
+		logger:   logging.For("websocket"),
+		upgrader: websocket.Upgrader{CheckOrigin: policy.CheckOrigin},
+		sessions: make(map[*wsSession]struct{}),
+	}
+}
+
+// ActiveSessions returns the number of open WebSocket connections
+func (h *WebSocketHandler) ActiveSessions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sessions)
+}
+
+// register adds a session unless the handler is draining
+func (h *WebSocketHandler) register(s *wsSession) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining {
+		return false
+	}
+	h.sessions[s] = struct{}{}
+	h.wg.Add(1)
+	return true
 }
 
-func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+// unregister removes a session once its handler has finished
+func (h *WebSocketHandler) unregister(s *wsSession) {
+	h.mu.Lock()
+	delete(h.sessions, s)
+	h.mu.Unlock()
+	h.wg.Done()
+}
+
+// Drain stops accepting sessions, asks every client to reconnect elsewhere and
+// waits for the session handlers to finish. Sessions still open when ctx
+// expires are closed forcefully.
+func (h *WebSocketHandler) Drain(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	sessions := make([]*wsSession, 0, len(h.sessions))
+	for s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.mu.Unlock()
+
+	h.logger.InfoContext(ctx, "Draining WebSocket sessions", "sessions", len(sessions))
+	for _, s := range sessions {
+		if err := s.close(websocket.CloseServiceRestart, RestartCloseReason); err != nil {
+			h.logger.DebugContext(ctx, "Failed to send close frame", "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		h.mu.Lock()
+		for s := range h.sessions {
+			s.conn.Close()
+		}
+		h.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// HandleWebSocket upgrades the request and serves the session until the client disconnects
+func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := logging.WithSessionID(r.Context(), logging.NewID())
+
 	// 升级 HTTP 连接为 WebSocket 连接
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
+		h.logger.WarnContext(ctx, "Failed to upgrade connection", "error", err)
 		return
 	}
 	defer conn.Close()
 
-	log.Printf("WebSocket connection established from %s", r.RemoteAddr)
+	session := &wsSession{conn: conn}
+	if !h.register(session) {
+		// 服务器正在关闭，让客户端重新连接到其他实例
+		if err := session.close(websocket.CloseServiceRestart, RestartCloseReason); err != nil {
+			h.logger.DebugContext(ctx, "Failed to send close frame", "error", err)
+		}
+		return
+	}
+	defer h.unregister(session)
+
+	h.logger.InfoContext(ctx, "WebSocket connection established", "remote_addr", r.RemoteAddr)
 
 	// 消息读取循环
 	for {
 		messageType, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseServiceRestart) {
+				h.logger.WarnContext(ctx, "WebSocket error", "error", err)
 			}
-			log.Printf("WebSocket connection closed from %s", r.RemoteAddr)
+			h.logger.InfoContext(ctx, "WebSocket connection closed", "remote_addr", r.RemoteAddr)
 			break
 		}
 
-		// 打印接收到的消息
-		log.Printf("Received message (type %d) from %s: %s", messageType, r.RemoteAddr, string(message))
+		// 只记录消息类型和大小，消息内容可能包含用户语音或文本
+		h.logger.DebugContext(ctx, "Received message", "type", messageType, "bytes", len(message))
 
 		// TODO: 在这里添加消息处理逻辑（如翻译）
 		// 现在只是简单地记录消息
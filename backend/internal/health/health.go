@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// checkTimeout bounds how long a single dependency check may take
+	checkTimeout = 5 * time.Second
+	// failureTTL caps how long a failed result is cached, so recovery is noticed quickly
+	failureTTL = 30 * time.Second
+)
+
+// CheckFunc checks one dependency. It returns an optional human-readable
+// detail and an error if the dependency is not usable.
+type CheckFunc func(ctx context.Context) (string, error)
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the readiness response body
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name string
+	ttl  time.Duration
+	fn   CheckFunc
+
+	mu     sync.Mutex
+	result Result
+	expiry time.Time
+}
+
+// run returns the cached result or runs the check if the cache has expired
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(c.expiry) {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	detail, err := c.fn(ctx)
+	c.result = Result{Status: "ok", Detail: detail, CheckedAt: now}
+	ttl := c.ttl
+	if err != nil {
+		c.result.Status = "fail"
+		c.result.Error = err.Error()
+		ttl = min(ttl, failureTTL)
+	}
+	c.expiry = now.Add(ttl)
+	return c.result
+}
+
+// Checker aggregates dependency checks into liveness and readiness endpoints
+type Checker struct {
+	mu       sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+	started  time.Time
+}
+
+// NewChecker creates a checker with no registered checks
+func NewChecker() *Checker {
+	return &Checker{started: time.Now()}
+}
+
+// Add registers a readiness check. Results are cached for ttl so that
+// expensive checks (for example calls to Speechmatics) are not run on every probe.
+func (c *Checker) Add(name string, ttl time.Duration, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &check{name: name, ttl: ttl, fn: fn})
+}
+
+// SetDraining marks the process as draining; readiness reports not ready from then on
+func (c *Checker) SetDraining(draining bool) {
+	c.draining.Store(draining)
+}
+
+// Draining reports whether the process is draining
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Ready runs all checks and reports whether the process can accept new work
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	report := Report{Status: "ready", Checks: make(map[string]Result, len(checks))}
+	ready := true
+	for _, chk := range checks {
+		result := chk.run(ctx)
+		report.Checks[chk.name] = result
+		if result.Status != "ok" {
+			ready = false
+		}
+	}
+
+	if !ready {
+		report.Status = "not_ready"
+	}
+	if c.Draining() {
+		report.Status = "draining"
+		ready = false
+	}
+	return report, ready
+}
+
+// LivenessHandler reports that the process is running
+func (c *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "ok",
+			"uptime": time.Since(c.started).Round(time.Second).String(),
+		})
+	}
+}
+
+// ReadinessHandler reports whether the process is ready to receive traffic
+func (c *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ready := c.Ready(r.Context())
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	}
+}
+
+// SessionLoad returns a check that fails when the number of active sessions
+// reaches the limit
+func SessionLoad(active func() int, limit func() int) CheckFunc {
+	return func(context.Context) (string, error) {
+		n, maxSessions := active(), limit()
+		detail := fmt.Sprintf("%d/%d sessions", n, maxSessions)
+		if maxSessions > 0 && n >= maxSessions {
+			return detail, fmt.Errorf("session limit reached")
+		}
+		return detail, nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
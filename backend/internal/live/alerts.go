@@ -0,0 +1,37 @@
+package live
+
+import (
+	"context"
+
+	"github.com/dreamtrans/backend/internal/alerts"
+)
+
+// WithAlerts checks final events against the alert rules of engine. Alerts
+// are published to the subscribers of the broadcast and passed to notifier,
+// which may be nil.
+func WithAlerts(engine *alerts.Engine, notifier alerts.Notifier) Option {
+	return func(h *Hub) {
+		h.alerts = engine
+		h.notifier = notifier
+	}
+}
+
+// raiseAlerts publishes an alert event for each rule the final event
+// matches. The caller holds b.mu.
+func (b *Broadcast) raiseAlerts(final Event) {
+	for _, a := range b.matcher.Match(alerts.Segment{
+		Speaker:      final.Speaker,
+		SpeakerLabel: final.SpeakerLabel,
+		Text:         final.Text,
+		StartTime:    final.StartTime,
+		EndTime:      final.EndTime,
+	}) {
+		a.SessionID = b.stored.ID()
+		a.LiveCode = b.Code
+		b.publish(Event{Type: EventAlert, Segment: final.ID, Alert: &a})
+		if b.notifier != nil {
+			b.notifier.Notify(context.Background(), a)
+		}
+		b.logger.Info("Alert raised", "live_code", b.Code, "rule", a.Rule)
+	}
+}
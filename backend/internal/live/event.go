@@ -0,0 +1,132 @@
+package live
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/partials"
+)
+
+// Event types
+const (
+	EventPartial            = "partial"
+	EventFinal              = "final"
+	EventPartialTranslation = "partial_translation"
+	EventTranslation        = "translation"
+	EventPartialDiff        = "partial_diff"
+	EventUtterance          = "utterance"
+	EventAlert              = "alert"
+	EventSpeakers           = "speakers"
+	EventEnd                = "end"
+)
+
+// OriginalLanguage selects the source transcript in a language filter
+const OriginalLanguage = "original"
+
+// Event is one message of a broadcast. Transcript events have an empty
+// Language; translations carry their target language. Utterance events
+// carry finals assembled into a complete sentence or utterance.
+type Event struct {
+	ID           uint64    `json:"id"`
+	Type         string    `json:"type"`
+	Time         time.Time `json:"time"`
+	Language     string    `json:"language,omitempty"`
+	Speaker      string    `json:"speaker,omitempty"`
+	SpeakerLabel string    `json:"speaker_label,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	StartTime    float64   `json:"start_time,omitempty"`
+	EndTime      float64   `json:"end_time,omitempty"`
+	// Keep is the number of UTF-16 code units of the previous partial that
+	// a partial_diff event keeps before appending Text
+	Keep int `json:"keep,omitempty"`
+	// Reason is why an utterance ended, such as "punctuation" or
+	// "speaker_change"
+	Reason string `json:"reason,omitempty"`
+	// Segment is the ID of the final event that an on-demand translation
+	// translates
+	Segment uint64 `json:"segment,omitempty"`
+	// Speakers is the full speaker name mapping on speakers events
+	Speakers map[string]string `json:"speakers,omitempty"`
+	// Alert is the alert raised by the final event Segment on alert events
+	Alert *alerts.Alert `json:"alert,omitempty"`
+}
+
+// IsText reports whether the event carries transcript or translation text
+func (e Event) IsText() bool {
+	switch e.Type {
+	case EventPartial, EventFinal, EventPartialTranslation, EventTranslation, EventPartialDiff, EventUtterance:
+		return true
+	}
+	return false
+}
+
+// IsPartial reports whether the event will be superseded by a final
+func (e Event) IsPartial() bool {
+	return e.Type == EventPartial || e.Type == EventPartialTranslation || e.Type == EventPartialDiff
+}
+
+// PartialDiffer sends partials to one subscriber as partial_diff events
+// against the previous partial of the same language, so that clients render
+// the stable prefix without flicker. A nil PartialDiffer leaves events as
+// they are.
+type PartialDiffer struct {
+	differ *partials.Differ
+}
+
+// NewPartialDiffer creates a differ for one subscriber
+func NewPartialDiffer() *PartialDiffer {
+	return &PartialDiffer{differ: partials.NewDiffer()}
+}
+
+// Apply converts partial events into partial_diff events and resets the
+// language of final events
+func (p *PartialDiffer) Apply(ev Event) Event {
+	if p == nil {
+		return ev
+	}
+	switch ev.Type {
+	case EventPartial, EventPartialTranslation:
+		diff := p.differ.Partial(ev.Language, ev.Text)
+		ev.Type, ev.Keep, ev.Text = EventPartialDiff, diff.Keep, diff.Text
+	case EventFinal, EventTranslation:
+		p.differ.Final(ev.Language)
+	}
+	return ev
+}
+
+// Filter selects the events delivered to a subscriber. Languages lists the
+// translation languages to receive, with OriginalLanguage for the source
+// transcript; an empty list receives everything. Events without text, such
+// as speakers and end, are always delivered.
+type Filter struct {
+	Languages []string
+}
+
+// ParseFilter parses a comma separated language list such as "original,ja"
+func ParseFilter(languages string) Filter {
+	var f Filter
+	for _, l := range strings.Split(languages, ",") {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			f.Languages = append(f.Languages, l)
+		}
+	}
+	return f
+}
+
+// Match reports whether the filter selects the event
+func (f Filter) Match(e Event) bool {
+	if len(f.Languages) == 0 || !e.IsText() {
+		return true
+	}
+	want := OriginalLanguage
+	if e.Language != "" {
+		want = strings.ToLower(e.Language)
+	}
+	for _, l := range f.Languages {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,586 @@
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/summarize"
+	"github.com/dreamtrans/backend/internal/translate"
+	"github.com/dreamtrans/backend/internal/utterance"
+	"github.com/dreamtrans/backend/internal/webhooks"
+)
+
+const (
+	// codeAlphabet leaves out characters that are easily confused when a
+	// join code is read out loud or typed (0/O, 1/I)
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	codeLength   = 6
+)
+
+var (
+	// ErrNotFound is returned for unknown or expired join codes
+	ErrNotFound = errors.New("broadcast not found")
+	// ErrEnded is returned when publishing to a broadcast that has ended
+	ErrEnded = errors.New("broadcast has ended")
+	// ErrSlow is the reason a subscriber was disconnected for not keeping up
+	ErrSlow = errors.New("subscriber too slow")
+	// ErrShutdown is the reason subscribers are disconnected at shutdown
+	ErrShutdown = errors.New("server shutting down")
+)
+
+// Hub holds the live broadcasts of this server. Broadcasts live in memory;
+// when a session store is set their transcript is also stored as a session.
+type Hub struct {
+	cfg         config.LiveConfig
+	sessions    *sessions.Store
+	translator  translate.Translator
+	translation config.TranslationConfig
+	summarizer  summarize.Summarizer
+	summary     config.SummaryConfig
+	alerts      *alerts.Engine
+	notifier    alerts.Notifier
+	webhooks    *webhooks.Dispatcher
+	logger      *slog.Logger
+
+	mu         sync.Mutex
+	broadcasts map[string]*Broadcast
+	// shutdownOnce also makes concurrent Shutdown calls wait for the first
+	shutdownOnce sync.Once
+}
+
+// Option configures optional Hub features
+type Option func(*Hub)
+
+// NewHub creates a hub. store may be nil when session storage is disabled.
+func NewHub(cfg config.LiveConfig, store *sessions.Store, opts ...Option) *Hub {
+	h := &Hub{
+		cfg:        cfg,
+		sessions:   store,
+		logger:     logging.For("live"),
+		broadcasts: make(map[string]*Broadcast),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Create starts a broadcast under a new join code. language is the source
+// language of the transcript, used for on-demand translation; it may be empty.
+func (h *Hub) Create(ctx context.Context, title, language string) (*Broadcast, error) {
+	key, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	code, err := h.newCode()
+	if err != nil {
+		return nil, err
+	}
+	b := &Broadcast{
+		Code:        code,
+		Title:       strings.TrimSpace(title),
+		Language:    strings.TrimSpace(language),
+		CreatedAt:   time.Now().UTC(),
+		key:         key,
+		cfg:         h.cfg,
+		logger:      h.logger,
+		subscribers: make(map[*Subscriber]struct{}),
+		segmenter:   utterance.NewSegmenter(),
+		matcher:     h.alerts.Matcher(),
+		notifier:    h.notifier,
+		webhooks:    h.webhooks,
+		lastEvent:   time.Now(),
+	}
+	if h.translator != nil {
+		var tctx context.Context
+		tctx, b.stopTranslating = context.WithCancel(context.Background())
+		b.translator = h.translator
+		b.translation = h.translation
+		b.jobs = make(chan translationJob, h.cfg.SubscriberBuffer)
+		b.translated = make(map[uint64]map[string]string)
+		go b.translateLoop(tctx)
+	}
+
+	// Writer is nil when session storage is disabled
+	if h.sessions != nil {
+		s := &sessions.Session{
+			Title:    b.Title,
+			Language: b.Language,
+			Source:   sessions.SourceLive,
+			Metadata: map[string]string{"live_code": code},
+		}
+		if b.stored, err = h.sessions.Start(ctx, s); err != nil {
+			h.logger.ErrorContext(ctx, "Failed to store broadcast", "live_code", code, "error", err)
+		}
+		if h.summarizer != nil {
+			b.summarize = h.summarizeSession
+		}
+	}
+
+	h.broadcasts[code] = b
+	b.emitSession(webhooks.EventSessionStarted)
+	metrics.LiveBroadcasts.Inc()
+	h.logger.InfoContext(ctx, "Broadcast created", "live_code", code, "stored_session_id", b.stored.ID())
+	return b, nil
+}
+
+// newCode returns an unused join code. The caller holds h.mu.
+func (h *Hub) newCode() (string, error) {
+	buf := make([]byte, codeLength)
+	for attempt := 0; attempt < 10; attempt++ {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate join code: %w", err)
+		}
+		for i, c := range buf {
+			buf[i] = codeAlphabet[int(c)%len(codeAlphabet)]
+		}
+		if _, taken := h.broadcasts[string(buf)]; !taken {
+			return string(buf), nil
+		}
+	}
+	return "", errors.New("failed to generate an unused join code")
+}
+
+// Get returns the broadcast with the join code. Codes are case-insensitive.
+func (h *Hub) Get(code string) (*Broadcast, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	b, ok := h.broadcasts[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+// BySession returns the broadcast whose transcript is stored as the session
+// with the ID
+func (h *Hub) BySession(id string) (*Broadcast, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, b := range h.broadcasts {
+		if id != "" && b.stored.ID() == id {
+			return b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Run removes broadcasts that have been idle for longer than the idle
+// timeout until ctx is canceled. Idle broadcasts that have not ended are
+// ended first, so their subscribers are told and their session is stored.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(min(h.cfg.IdleTimeout/2, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.reap(time.Now())
+		}
+	}
+}
+
+// reap removes broadcasts idle since before now minus the idle timeout
+func (h *Hub) reap(now time.Time) {
+	h.mu.Lock()
+	var idle []*Broadcast
+	for code, b := range h.broadcasts {
+		if now.Sub(b.LastEvent()) > h.cfg.IdleTimeout {
+			idle = append(idle, b)
+			delete(h.broadcasts, code)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, b := range idle {
+		b.End()
+		h.logger.Info("Broadcast expired", "live_code", b.Code)
+	}
+}
+
+// Shutdown disconnects every subscriber and stores the transcripts of
+// running broadcasts. It is called when the server shuts down so that
+// long-lived subscriber requests finish. The work is done once; later and
+// concurrent calls return when the transcripts are stored.
+func (h *Hub) Shutdown() {
+	h.shutdownOnce.Do(func() {
+		h.mu.Lock()
+		all := make([]*Broadcast, 0, len(h.broadcasts))
+		for _, b := range h.broadcasts {
+			all = append(all, b)
+		}
+		h.mu.Unlock()
+
+		for _, b := range all {
+			b.shutdown()
+		}
+	})
+}
+
+// Broadcast is one live transcript with one producer and any number of
+// read-only subscribers
+type Broadcast struct {
+	Code      string
+	Title     string
+	Language  string
+	CreatedAt time.Time
+
+	key    string
+	cfg    config.LiveConfig
+	logger *slog.Logger
+	stored *sessions.Writer
+	// summarize is set when the stored session is summarized at the end
+	summarize func(id string)
+	// matcher is nil when there are no alert rules
+	matcher  *alerts.Matcher
+	notifier alerts.Notifier
+	// webhooks is nil when no webhook sinks are configured
+	webhooks *webhooks.Dispatcher
+
+	// translator is nil when on-demand translation is disabled
+	translator      translate.Translator
+	translation     config.TranslationConfig
+	jobs            chan translationJob
+	stopTranslating context.CancelFunc
+
+	mu          sync.Mutex
+	nextID      uint64
+	backlog     []Event
+	subscribers map[*Subscriber]struct{}
+	speakers    map[string]string
+	segmenter   *utterance.Segmenter
+	lastEvent   time.Time
+	ended       bool
+	finals      int
+	// native are the translation languages the producer publishes,
+	// generated those translated on demand
+	native    map[string]bool
+	generated []string
+	// translated caches on-demand translations by segment and language
+	translated map[uint64]map[string]string
+}
+
+// Info describes a broadcast
+type Info struct {
+	Code     string `json:"code"`
+	Title    string `json:"title,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Translations lists the languages published by the producer or
+	// translated on demand
+	Translations []string  `json:"translations,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Ended        bool      `json:"ended"`
+	Subscribers  int       `json:"subscribers"`
+	Events       uint64    `json:"events"`
+	SessionID    string    `json:"session_id,omitempty"`
+}
+
+// Info returns the current state of the broadcast
+func (b *Broadcast) Info() Info {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	translations := slices.Clone(b.generated)
+	for l := range b.native {
+		translations = append(translations, l)
+	}
+	slices.Sort(translations)
+	return Info{
+		Code:         b.Code,
+		Title:        b.Title,
+		Language:     b.Language,
+		Translations: translations,
+		CreatedAt:    b.CreatedAt,
+		Ended:        b.ended,
+		Subscribers:  len(b.subscribers),
+		Events:       b.nextID,
+		SessionID:    b.stored.ID(),
+	}
+}
+
+// Key returns the secret the producer needs to publish
+func (b *Broadcast) Key() string {
+	return b.key
+}
+
+// Authorize reports whether key allows publishing to the broadcast
+func (b *Broadcast) Authorize(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(key), []byte(b.key)) == 1
+}
+
+// LastEvent returns when the broadcast last published an event
+func (b *Broadcast) LastEvent() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastEvent
+}
+
+// Publish assigns the event its ID, applies speaker names and delivers it to
+// the subscribers. Final events are kept for replay to late joiners.
+func (b *Broadcast) Publish(ev Event) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return Event{}, ErrEnded
+	}
+
+	if ev.Speaker != "" {
+		ev.SpeakerLabel = ev.Speaker
+		if name, ok := b.speakers[ev.Speaker]; ok {
+			ev.Speaker = name
+		}
+	}
+	switch ev.Type {
+	case EventFinal:
+		b.stored.AddSegment(sessions.Segment{Speaker: ev.SpeakerLabel, Text: ev.Text, StartTime: ev.StartTime, EndTime: ev.EndTime})
+	case EventTranslation, EventPartialTranslation:
+		b.markNative(ev.Language)
+		if ev.Type == EventTranslation {
+			b.stored.AddTranslation(sessions.Translation{Language: ev.Language, Speaker: ev.SpeakerLabel, Text: ev.Text, StartTime: ev.StartTime, EndTime: ev.EndTime})
+		}
+	}
+	ev = b.publish(ev)
+	if ev.Type == EventFinal {
+		b.translateFinal(ev)
+		b.raiseAlerts(ev)
+		b.publishUtterances(b.segmenter.Add(ev.SpeakerLabel, ev.Text, ev.StartTime, ev.EndTime))
+	}
+	return ev, nil
+}
+
+// EndOfUtterance completes the current utterance when the producer's engine
+// detected the end of an utterance
+func (b *Broadcast) EndOfUtterance() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return ErrEnded
+	}
+	b.publishUtterances(b.segmenter.EndOfUtterance())
+	return nil
+}
+
+// publishUtterances publishes completed utterances. The caller holds b.mu.
+func (b *Broadcast) publishUtterances(utterances []utterance.Utterance) {
+	for _, u := range utterances {
+		speaker := u.Speaker
+		if name, ok := b.speakers[speaker]; ok {
+			speaker = name
+		}
+		b.publish(Event{
+			Type:         EventUtterance,
+			Speaker:      speaker,
+			SpeakerLabel: u.Speaker,
+			Text:         u.Text,
+			StartTime:    u.StartTime,
+			EndTime:      u.EndTime,
+			Reason:       u.Reason,
+		})
+	}
+}
+
+// RenameSpeakers names speaker labels for all following events and tells
+// the subscribers the new names
+func (b *Broadcast) RenameSpeakers(names map[string]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return ErrEnded
+	}
+	merged, err := sessions.MergeSpeakers(b.speakers, names)
+	if err != nil {
+		return err
+	}
+	b.speakers = merged
+	if err := b.stored.RenameSpeakers(names); err != nil {
+		b.logger.Warn("Failed to store speaker names", "live_code", b.Code, "error", err)
+	}
+	speakers := merged
+	if speakers == nil {
+		speakers = map[string]string{}
+	}
+	b.publish(Event{Type: EventSpeakers, Speakers: speakers})
+	return nil
+}
+
+// End finishes the broadcast: subscribers receive an end event and are
+// disconnected, and the transcript is stored. Ending twice is a no-op.
+func (b *Broadcast) End() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return
+	}
+	b.publishUtterances(b.segmenter.Flush())
+	b.publish(Event{Type: EventEnd})
+	b.ended = true
+	metrics.LiveBroadcasts.Dec()
+	b.emitSession(webhooks.EventSessionEnded)
+	if b.stopTranslating != nil {
+		b.stopTranslating()
+	}
+	for sub := range b.subscribers {
+		b.remove(sub, nil)
+	}
+	if err := b.stored.Close(); err != nil {
+		b.logger.Error("Failed to store broadcast", "live_code", b.Code, "error", err)
+	} else if b.summarize != nil && b.stored != nil {
+		go b.summarize(b.stored.ID())
+	}
+	b.logger.Info("Broadcast ended", "live_code", b.Code, "events", b.nextID)
+}
+
+// shutdown disconnects the subscribers without ending the broadcast, which
+// goes away with the process
+func (b *Broadcast) shutdown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopTranslating != nil {
+		b.stopTranslating()
+	}
+	for sub := range b.subscribers {
+		b.remove(sub, ErrShutdown)
+	}
+	if err := b.stored.Close(); err != nil {
+		b.logger.Error("Failed to store broadcast", "live_code", b.Code, "error", err)
+	}
+}
+
+// publish stamps and delivers an event. The caller holds b.mu.
+func (b *Broadcast) publish(ev Event) Event {
+	b.nextID++
+	ev.ID = b.nextID
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	b.lastEvent = time.Now()
+	if ev.Type == EventFinal {
+		b.finals++
+	}
+	b.emitText(ev)
+
+	if !ev.IsPartial() {
+		b.backlog = append(b.backlog, ev)
+		if over := len(b.backlog) - b.cfg.BacklogSize; over > 0 {
+			b.backlog = append(b.backlog[:0], b.backlog[over:]...)
+		}
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			// A subscriber that cannot keep up must not hold back the others
+			b.remove(sub, ErrSlow)
+			b.logger.Warn("Disconnected slow subscriber", "live_code", b.Code)
+		}
+	}
+	return ev
+}
+
+// remove unsubscribes and closes a subscriber. The caller holds b.mu.
+func (b *Broadcast) remove(sub *Subscriber, err error) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+// Subscribe joins the broadcast. The returned replay holds the stored events
+// after the event ID after (0 for all) that match the filter; the caller
+// sends them before reading from the subscriber. Subscribing to an ended
+// broadcast replays its backlog and returns a closed subscriber.
+func (b *Broadcast) Subscribe(filter Filter, after uint64) (*Subscriber, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	for _, ev := range b.backlog {
+		if ev.ID > after && filter.Match(ev) {
+			replay = append(replay, ev)
+		}
+	}
+
+	sub := &Subscriber{
+		b:      b,
+		filter: filter,
+		events: make(chan Event, b.cfg.SubscriberBuffer),
+	}
+	if b.ended {
+		close(sub.events)
+		return sub, replay
+	}
+	b.subscribers[sub] = struct{}{}
+	b.requestLanguages(filter)
+	return sub, replay
+}
+
+// Subscriber receives the events of a broadcast
+type Subscriber struct {
+	b *Broadcast
+	// filter, events and err are guarded by b.mu
+	filter Filter
+	events chan Event
+	err    error
+}
+
+// Events returns the event channel. It is closed when the broadcast ends,
+// the subscriber is too slow or Close is called.
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns why the event channel was closed: nil when the broadcast ended
+// or the subscriber closed, ErrSlow or ErrShutdown otherwise
+func (s *Subscriber) Err() error {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.err
+}
+
+// SetFilter changes the events delivered from now on. Languages that are
+// not published yet are translated on demand, starting with the backlog.
+func (s *Subscriber) SetFilter(f Filter) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.filter = f
+	s.b.requestLanguages(f)
+}
+
+// Close leaves the broadcast
+func (s *Subscriber) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.remove(s, nil)
+}
+
+// randomKey returns a producer key
+func randomKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate producer key: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,72 @@
+package live
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestShutdownStoresFinals(t *testing.T) {
+	store, err := sessions.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := NewHub(config.Default().Live, store)
+	b, err := hub.Create(context.Background(), "test", "en")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	publishFinal(t, b, "hello")
+	publishFinal(t, b, "world")
+
+	// The server starts Shutdown in the background and main calls it again;
+	// every call returns only once the transcript is stored
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.Shutdown()
+		}()
+	}
+	wg.Wait()
+
+	s, err := store.Get(b.stored.ID())
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if len(s.Segments) != 2 || s.Segments[0].Text != "hello" || s.Segments[1].Text != "world" {
+		t.Errorf("stored segments = %+v, want hello and world", s.Segments)
+	}
+	if s.EndedAt == nil {
+		t.Error("stored session has no end time")
+	}
+}
+
+func TestBroadcastGaugeDecrementsOnEnd(t *testing.T) {
+	hub := NewHub(config.Default().Live, nil)
+	before := testutil.ToFloat64(metrics.LiveBroadcasts)
+	b, err := hub.Create(context.Background(), "test", "en")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.LiveBroadcasts); got != before+1 {
+		t.Fatalf("gauge after Create = %v, want %v", got, before+1)
+	}
+
+	b.End()
+	if got := testutil.ToFloat64(metrics.LiveBroadcasts); got != before {
+		t.Errorf("gauge after End = %v, want %v", got, before)
+	}
+	// Reaping the ended broadcast does not count it again
+	hub.reap(time.Now().Add(2 * hub.cfg.IdleTimeout))
+	if got := testutil.ToFloat64(metrics.LiveBroadcasts); got != before {
+		t.Errorf("gauge after reap = %v, want %v", got, before)
+	}
+}
@@ -0,0 +1,28 @@
+package live
+
+import (
+	"context"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/summarize"
+)
+
+// WithSummarizer summarizes the stored session of a broadcast when it ends
+func WithSummarizer(s summarize.Summarizer, cfg config.SummaryConfig) Option {
+	return func(h *Hub) {
+		h.summarizer = s
+		h.summary = cfg
+	}
+}
+
+// summarizeSession summarizes the stored session id in the background
+func (h *Hub) summarizeSession(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.summary.Timeout)
+	defer cancel()
+	sum, err := summarize.Session(ctx, h.summarizer, h.sessions, id)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to summarize broadcast", "stored_session_id", id, "error", err)
+		return
+	}
+	h.logger.InfoContext(ctx, "Broadcast summarized", "stored_session_id", id, "decisions", len(sum.Decisions), "action_items", len(sum.ActionItems))
+}
@@ -0,0 +1,175 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/translate"
+)
+
+// WithTranslator translates final events into languages that subscribers
+// ask for and that the producer does not publish itself
+func WithTranslator(t translate.Translator, cfg config.TranslationConfig) Option {
+	return func(h *Hub) {
+		h.translator = t
+		h.translation = cfg
+	}
+}
+
+// translationJob asks the translation worker to translate final events
+type translationJob struct {
+	language string
+	segments []Event
+}
+
+// requestLanguages starts translating into the languages of the filter that
+// the producer does not publish. A new language is first translated for the
+// final events in the backlog, so a subscriber switching language mid-session
+// catches up. The caller holds b.mu.
+func (b *Broadcast) requestLanguages(f Filter) {
+	if b.jobs == nil {
+		return
+	}
+	for _, l := range f.Languages {
+		if l == OriginalLanguage || l == strings.ToLower(b.Language) || b.native[l] || slices.Contains(b.generated, l) {
+			continue
+		}
+		if len(b.generated) >= b.translation.MaxLanguages {
+			b.logger.Warn("Too many translation languages, ignoring request", "live_code", b.Code, "language", l)
+			continue
+		}
+		b.generated = append(b.generated, l)
+		b.logger.Info("Translating broadcast on demand", "live_code", b.Code, "language", l)
+
+		var finals []Event
+		for _, ev := range b.backlog {
+			if ev.Type == EventFinal {
+				finals = append(finals, ev)
+			}
+		}
+		b.enqueue(translationJob{language: l, segments: finals})
+	}
+}
+
+// translateFinal queues a final event for every on-demand language. The
+// caller holds b.mu.
+func (b *Broadcast) translateFinal(ev Event) {
+	for _, l := range b.generated {
+		b.enqueue(translationJob{language: l, segments: []Event{ev}})
+	}
+}
+
+// markNative stops translating a language the producer publishes itself.
+// The caller holds b.mu.
+func (b *Broadcast) markNative(language string) {
+	l := strings.ToLower(language)
+	if l == "" || b.native[l] {
+		return
+	}
+	if b.native == nil {
+		b.native = make(map[string]bool)
+	}
+	b.native[l] = true
+	b.generated = slices.DeleteFunc(b.generated, func(g string) bool { return g == l })
+}
+
+// enqueue hands a job to the worker without blocking the publisher. The
+// caller holds b.mu.
+func (b *Broadcast) enqueue(job translationJob) {
+	if len(job.segments) == 0 {
+		return
+	}
+	select {
+	case b.jobs <- job:
+	default:
+		b.logger.Warn("Translation queue full, dropping segments", "live_code", b.Code, "language", job.language, "segments", len(job.segments))
+	}
+}
+
+// translateLoop runs the translation jobs of the broadcast until ctx is
+// canceled when the broadcast ends
+func (b *Broadcast) translateLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-b.jobs:
+			for _, seg := range job.segments {
+				if !b.translateSegment(ctx, seg, job.language) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// translateSegment translates one final event and publishes the result as a
+// translation event referring to the segment. Translations are cached by
+// segment, so each segment is translated once per language however many
+// subscribers ask for it. It returns false when the language should no
+// longer be translated.
+func (b *Broadcast) translateSegment(ctx context.Context, seg Event, language string) bool {
+	b.mu.Lock()
+	wanted := !b.ended && slices.Contains(b.generated, language)
+	_, cached := b.translated[seg.ID][language]
+	b.mu.Unlock()
+	if !wanted {
+		return false
+	}
+	if cached {
+		return true
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, b.translation.Timeout)
+	text, err := b.translator.Translate(tctx, seg.Text, b.Language, language)
+	cancel()
+	metrics.Translations.WithLabelValues(metrics.Outcome(err)).Inc()
+	if errors.Is(err, translate.ErrUnsupported) {
+		b.logger.Warn("Language cannot be translated", "live_code", b.Code, "language", language, "error", err)
+		b.mu.Lock()
+		b.generated = slices.DeleteFunc(b.generated, func(g string) bool { return g == language })
+		b.mu.Unlock()
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			b.logger.Warn("Failed to translate segment", "live_code", b.Code, "language", language, "error", err)
+		}
+		return ctx.Err() == nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended || !slices.Contains(b.generated, language) {
+		return false
+	}
+	if _, ok := b.translated[seg.ID][language]; ok {
+		return true
+	}
+	if b.translated[seg.ID] == nil {
+		b.translated[seg.ID] = make(map[string]string)
+	}
+	b.translated[seg.ID][language] = text
+
+	speaker := seg.SpeakerLabel
+	if name, ok := b.speakers[speaker]; ok {
+		speaker = name
+	}
+	b.stored.AddTranslation(sessions.Translation{Language: language, Speaker: seg.SpeakerLabel, Text: text, StartTime: seg.StartTime, EndTime: seg.EndTime})
+	b.publish(Event{
+		Type:         EventTranslation,
+		Language:     language,
+		Speaker:      speaker,
+		SpeakerLabel: seg.SpeakerLabel,
+		Text:         text,
+		StartTime:    seg.StartTime,
+		EndTime:      seg.EndTime,
+		Segment:      seg.ID,
+	})
+	return true
+}
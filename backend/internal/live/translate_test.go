@@ -0,0 +1,149 @@
+package live
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/translate"
+)
+
+// countingTranslator upper-cases text and counts the calls per text and
+// target language
+type countingTranslator struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingTranslator) Translate(_ context.Context, text, _, target string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[target+":"+text]++
+	if target == "xx" {
+		return "", translate.ErrUnsupported
+	}
+	return strings.ToUpper(text), nil
+}
+
+func (c *countingTranslator) count(target, text string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[target+":"+text]
+}
+
+func newTranslatingBroadcast(t *testing.T) (*Broadcast, *countingTranslator) {
+	t.Helper()
+	cfg := config.Default()
+	tr := &countingTranslator{calls: make(map[string]int)}
+	hub := NewHub(cfg.Live, nil, WithTranslator(tr, cfg.Translation))
+	b, err := hub.Create(context.Background(), "test", "en")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	t.Cleanup(b.End)
+	return b, tr
+}
+
+// next returns the next event of sub or fails after a second
+func next(t *testing.T, sub *Subscriber) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscriber closed: %v", sub.Err())
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func publishFinal(t *testing.T, b *Broadcast, text string) Event {
+	t.Helper()
+	ev, err := b.Publish(Event{Type: EventFinal, Text: text})
+	if err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	return ev
+}
+
+func TestOnDemandTranslationCachedPerSegment(t *testing.T) {
+	b, tr := newTranslatingBroadcast(t)
+	first := publishFinal(t, b, "hello")
+	second := publishFinal(t, b, "world")
+
+	// The first subscriber of a language gets the backlog translated
+	sub1, _ := b.Subscribe(ParseFilter("ja"), 0)
+	for _, want := range []Event{first, second} {
+		ev := next(t, sub1)
+		if ev.Type != EventTranslation || ev.Language != "ja" || ev.Segment != want.ID || ev.Text != strings.ToUpper(want.Text) {
+			t.Fatalf("got %+v, want the ja translation of segment %d", ev, want.ID)
+		}
+	}
+
+	// Later subscribers and finals reuse the language without translating
+	// any segment twice
+	sub2, replay := b.Subscribe(ParseFilter("ja"), 0)
+	if len(replay) != 2 {
+		t.Errorf("second subscriber replayed %d events, want 2 translations", len(replay))
+	}
+	third := publishFinal(t, b, "again")
+	for _, sub := range []*Subscriber{sub1, sub2} {
+		if ev := next(t, sub); ev.Segment != third.ID {
+			t.Errorf("got %+v, want the translation of segment %d", ev, third.ID)
+		}
+	}
+	if !b.translateSegment(context.Background(), first, "ja") {
+		t.Error("translateSegment of a cached segment returned false")
+	}
+	for _, text := range []string{"hello", "world", "again"} {
+		if n := tr.count("ja", text); n != 1 {
+			t.Errorf("%q translated %d times, want 1", text, n)
+		}
+	}
+}
+
+func TestOnDemandTranslationStopsForNativeLanguage(t *testing.T) {
+	b, tr := newTranslatingBroadcast(t)
+	sub, _ := b.Subscribe(ParseFilter("ja"), 0)
+	publishFinal(t, b, "hello")
+	if ev := next(t, sub); ev.Type != EventTranslation {
+		t.Fatalf("got %+v, want a translation", ev)
+	}
+
+	// Once the producer publishes the language, it is no longer translated
+	if _, err := b.Publish(Event{Type: EventTranslation, Language: "ja", Text: "こんにちは"}); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	if ev := next(t, sub); ev.Text != "こんにちは" {
+		t.Fatalf("got %+v, want the producer's translation", ev)
+	}
+	publishFinal(t, b, "world")
+	select {
+	case ev := <-sub.Events():
+		t.Fatalf("got %+v after the producer took over the language", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := tr.count("ja", "world"); n != 0 {
+		t.Errorf("native language translated %d times", n)
+	}
+}
+
+func TestOnDemandTranslationDropsUnsupportedLanguage(t *testing.T) {
+	b, _ := newTranslatingBroadcast(t)
+	publishFinal(t, b, "hello")
+	b.Subscribe(ParseFilter("xx"), 0)
+
+	deadline := time.Now().Add(time.Second)
+	for slices.Contains(b.Info().Translations, "xx") {
+		if time.Now().After(deadline) {
+			t.Fatal("unsupported language still translated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+package live
+
+import (
+	"context"
+
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/webhooks"
+)
+
+// WithWebhooks emits the lifecycle events of broadcasts, their finals and
+// their translations to webhook sinks
+func WithWebhooks(d *webhooks.Dispatcher) Option {
+	return func(h *Hub) {
+		h.webhooks = d
+	}
+}
+
+// sessionID identifies the broadcast in webhook events: its stored session,
+// or its join code when session storage is disabled
+func (b *Broadcast) sessionID() string {
+	if id := b.stored.ID(); id != "" {
+		return id
+	}
+	return b.Code
+}
+
+// emitSession emits a session.started or session.ended event. The caller
+// holds b.mu.
+func (b *Broadcast) emitSession(typ string) {
+	b.webhooks.Emit(context.Background(), typ, b.sessionID(), webhooks.Session{
+		Source:   sessions.SourceLive,
+		Title:    b.Title,
+		Language: b.Language,
+		LiveCode: b.Code,
+		Segments: b.finals,
+	})
+}
+
+// emitText emits final and translation events. The caller holds b.mu.
+func (b *Broadcast) emitText(ev Event) {
+	var typ string
+	switch ev.Type {
+	case EventFinal:
+		typ = webhooks.EventSegmentFinal
+	case EventTranslation:
+		typ = webhooks.EventTranslation
+	default:
+		return
+	}
+	b.webhooks.Emit(context.Background(), typ, b.sessionID(), webhooks.Segment{
+		Language:     ev.Language,
+		Speaker:      ev.Speaker,
+		SpeakerLabel: ev.SpeakerLabel,
+		Text:         ev.Text,
+		StartTime:    ev.StartTime,
+		EndTime:      ev.EndTime,
+	})
+}
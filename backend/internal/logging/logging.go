@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	mu     sync.Mutex
+	base   slog.Handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	global              = new(slog.LevelVar)
+	levels              = make(map[string]*slog.LevelVar)
+)
+
+// Config controls log output format and levels
+type Config struct {
+	// Level is the default level for all components (debug, info, warn, error)
+	Level string `yaml:"level"`
+	// Format is either "json" or "text"
+	Format string `yaml:"format"`
+	// Components overrides the level for individual components, e.g. {"speechmatics": "debug"}
+	Components map[string]string `yaml:"components"`
+}
+
+// Setup installs the JSON (or text) handler as the slog default and routes the
+// standard library logger through it. It should be called once at startup.
+func Setup(cfg Config, w io.Writer) error {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	mu.Lock()
+	base = handler
+	mu.Unlock()
+
+	if err := Apply(cfg); err != nil {
+		return err
+	}
+
+	// Route the standard library logger through slog as well
+	slog.SetDefault(For("main"))
+	return nil
+}
+
+// Apply updates the default and per-component levels. It is safe to call at
+// runtime; loggers that were already created pick up the new levels.
+func Apply(cfg Config) error {
+	defaultLevel, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	componentLevels := make(map[string]slog.Level, len(cfg.Components))
+	for name, level := range cfg.Components {
+		l, err := ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", name, err)
+		}
+		componentLevels[name] = l
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	global.Set(defaultLevel)
+	for name, lv := range levels {
+		if l, ok := componentLevels[name]; ok {
+			lv.Set(l)
+		} else {
+			lv.Set(defaultLevel)
+		}
+	}
+	for name, l := range componentLevels {
+		if _, ok := levels[name]; !ok {
+			lv := new(slog.LevelVar)
+			lv.Set(l)
+			levels[name] = lv
+		}
+	}
+	return nil
+}
+
+// ParseLevel converts a level name into a slog.Level. An empty name means info.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// For returns a logger for a component. Its level follows the component
+// override if one is configured, and the default level otherwise.
+func For(component string) *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
+	lv, ok := levels[component]
+	if !ok {
+		lv = new(slog.LevelVar)
+		lv.Set(global.Level())
+		levels[component] = lv
+	}
+
+	h := &contextHandler{next: base, level: lv}
+	return slog.New(h).With(slog.String("component", component))
+}
+
+// contextHandler filters records by component level and adds correlation IDs
+// and trace IDs stored in the context
+type contextHandler struct {
+	next  slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *contextHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := SessionID(ctx); id != "" {
+		r.AddAttrs(slog.String("session_id", id))
+	}
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name), level: h.level}
+}
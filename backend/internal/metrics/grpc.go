@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// StreamServerInterceptor counts open and finished gRPC streams
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	GRPCActiveStreams.Inc()
+	defer GRPCActiveStreams.Dec()
+
+	err := handler(srv, ss)
+	GRPCStreams.WithLabelValues(status.Code(err).String()).Inc()
+	return err
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import "strings"
+
+// otherLabel replaces label values outside the known set, so that client
+// input cannot create unbounded time series
+const otherLabel = "other"
+
+// languages are the language codes Speechmatics transcribes
+var languages = map[string]bool{}
+
+func init() {
+	for _, l := range strings.Fields(`auto ar ba be bg bn ca cmn cs cy da de el en eo es et eu fa fi fr ga gl
+		he hi hr hu ia id it ja ko lt lv mn mr ms mt nl no pl pt ro ru sk sl sv sw ta th tr ug uk ur vi yue zh`) {
+		languages[l] = true
+	}
+}
+
+// Language returns the language label for a requested language code
+func Language(code string) string {
+	if l := strings.ToLower(code); languages[l] {
+		return l
+	}
+	return otherLabel
+}
+
+// Engine returns the engine label for a requested operating point
+func Engine(operatingPoint string) string {
+	switch op := strings.ToLower(operatingPoint); op {
+	case "standard", "enhanced":
+		return op
+	}
+	return otherLabel
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import "testing"
+
+func TestLabels(t *testing.T) {
+	tests := []struct {
+		fn        func(string) string
+		in, label string
+	}{
+		{Language, "en", "en"},
+		{Language, "JA", "ja"},
+		{Language, "cmn", "cmn"},
+		{Language, "", otherLabel},
+		{Language, "en-US", otherLabel},
+		{Language, "../../etc/passwd", otherLabel},
+		{Engine, "enhanced", "enhanced"},
+		{Engine, "Standard", "standard"},
+		{Engine, "turbo", otherLabel},
+		{Engine, "", otherLabel},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.label {
+			t.Errorf("label of %q = %q, want %q", tt.in, got, tt.label)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "dreamtrans"
+
+// latencyBuckets covers sub-second partials up to multi-second finals
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 3, 5, 8, 13, 20}
+
+var (
+	// ActiveSessions is the number of realtime sessions currently streaming to Speechmatics
+	ActiveSessions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "realtime_active_sessions",
+		Help:      "Number of realtime transcription sessions in progress.",
+	}, []string{"language", "engine"})
+
+	// AudioBytes counts raw audio bytes received for realtime transcription
+	AudioBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "realtime_audio_bytes_total",
+		Help:      "Audio bytes received for realtime transcription.",
+	}, []string{"language", "engine"})
+
+	// AudioSeconds counts seconds of audio received for realtime transcription
+	AudioSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "realtime_audio_seconds_total",
+		Help:      "Seconds of audio received for realtime transcription.",
+	}, []string{"language", "engine"})
+
+	// PartialToFinalLatency measures the time from the first partial of a segment to its final
+	PartialToFinalLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "realtime_partial_to_final_seconds",
+		Help:      "Time between the first partial transcript of a segment and its final transcript.",
+		Buckets:   latencyBuckets,
+	}, []string{"language", "engine"})
+
+	// TimeToFirstTranscript measures the time from StartRecognition to the first transcript
+	TimeToFirstTranscript = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "realtime_time_to_first_transcript_seconds",
+		Help:      "Time between starting recognition and receiving the first transcript.",
+		Buckets:   latencyBuckets,
+	}, []string{"language", "engine"})
+
+	// UpstreamFailures counts failed realtime connections to Speechmatics
+	UpstreamFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_dial_failures_total",
+		Help:      "Failed Speechmatics realtime connections by region and reason.",
+	}, []string{"region", "reason"})
+
+	// BatchJobs counts batch jobs by the status they were last observed in
+	BatchJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "batch_jobs_total",
+		Help:      "Batch transcription jobs by status.",
+	}, []string{"status", "language", "engine"})
+
+	// BatchJobDuration measures the time from submission until a job reaches a final status
+	BatchJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "batch_job_duration_seconds",
+		Help:      "Time from batch job submission until it reached a final status.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"status", "language", "engine"})
+
+	// TokenLatency measures the time taken to mint temporary realtime keys
+	TokenLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "token_mint_seconds",
+		Help:      "Latency of minting Speechmatics temporary keys.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	// GRPCActiveStreams is the number of open gRPC transcription streams
+	GRPCActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "grpc_active_streams",
+		Help:      "Number of open gRPC transcription streams.",
+	})
+
+	// GRPCStreams counts finished gRPC transcription streams by status code
+	GRPCStreams = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_streams_total",
+		Help:      "Finished gRPC transcription streams by status code.",
+	}, []string{"code"})
+
+	// RejectedOrigins counts requests refused by the origin policy
+	RejectedOrigins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rejected_origins_total",
+		Help:      "Requests rejected by the origin policy, by source (cors or websocket).",
+	}, []string{"source"})
+
+	// LiveBroadcasts is the number of live broadcasts that have not ended
+	LiveBroadcasts = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "live_broadcasts",
+		Help:      "Number of live broadcasts that have not ended.",
+	})
+
+	// LiveSubscribers is the number of connected broadcast subscribers
+	LiveSubscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "live_subscribers",
+		Help:      "Number of connected live broadcast subscribers, by transport (websocket or sse).",
+	}, []string{"transport"})
+
+	// Translations counts machine translated segments
+	Translations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "translations_total",
+		Help:      "Segments translated by the machine translation backend, by result.",
+	}, []string{"result"})
+
+	// Alerts counts alerts raised on realtime transcripts
+	Alerts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_total",
+		Help:      "Alerts raised on realtime transcripts, by rule.",
+	}, []string{"rule"})
+
+	// AlertDeliveries counts alert webhook deliveries
+	AlertDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alert_deliveries_total",
+		Help:      "Alert webhook deliveries, by result.",
+	}, []string{"result"})
+
+	// WebhookDeliveries counts event deliveries to webhook sinks
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Event deliveries to webhook sinks, by sink and result (success, retry, dead_letter or dropped).",
+	}, []string{"sink", "result"})
+
+	// PCASEvents counts transcript events published to the PCAS event bus
+	PCASEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pcas_events_total",
+		Help:      "Events published to the PCAS event bus, by event type and result (success, error or dropped).",
+	}, []string{"type", "result"})
+)
+
+// Handler returns the HTTP handler that serves the /metrics endpoint
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Outcome returns the outcome label for an operation result
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// Since returns the seconds elapsed since start, for use with Observe
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,108 @@
+package origin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/rs/cors"
+)
+
+// Policy decides which browser origins may call the API and open WebSocket
+// sessions. Entries are either exact origins ("https://app.example.com") or
+// patterns with wildcards ("https://*.example.com", "http://localhost:*").
+type Policy struct {
+	exact    map[string]struct{}
+	patterns []string
+	logger   *slog.Logger
+}
+
+// NewPolicy creates a policy from the allowed origins list
+func NewPolicy(allowed []string) (*Policy, error) {
+	p := &Policy{
+		exact:  make(map[string]struct{}),
+		logger: logging.For("origin"),
+	}
+	for _, entry := range allowed {
+		entry = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), "/")
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return nil, fmt.Errorf("wildcard origin \"*\" is not allowed, list the origins explicitly")
+		}
+		if strings.ContainsAny(entry, "*?[") {
+			if _, err := path.Match(entry, ""); err != nil {
+				return nil, fmt.Errorf("invalid origin pattern %q: %w", entry, err)
+			}
+			p.patterns = append(p.patterns, entry)
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid origin %q: expected scheme://host[:port]", entry)
+		}
+		p.exact[entry] = struct{}{}
+	}
+	return p, nil
+}
+
+// Allowed reports whether the origin matches the policy
+func (p *Policy) Allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	if _, ok := p.exact[origin]; ok {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowRequest applies the policy to a request. Requests without an Origin
+// header (non-browser clients) and same-origin requests are always allowed.
+func (p *Policy) allowRequest(r *http.Request, origin, source string) bool {
+	if origin == "" || sameOrigin(r, origin) || p.Allowed(origin) {
+		return true
+	}
+	p.logger.WarnContext(r.Context(), "Rejected origin", "origin", origin, "source", source, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+	metrics.RejectedOrigins.WithLabelValues(source).Inc()
+	return false
+}
+
+// CheckOrigin is used as the WebSocket upgrader's origin check
+func (p *Policy) CheckOrigin(r *http.Request) bool {
+	return p.allowRequest(r, r.Header.Get("Origin"), "websocket")
+}
+
+// CORS returns the CORS middleware configured with the policy
+func (p *Policy) CORS() *cors.Cors {
+	return cors.New(cors.Options{
+		AllowOriginVaryRequestFunc: func(r *http.Request, origin string) (bool, []string) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				// Upgrades are checked (and counted) by CheckOrigin
+				return sameOrigin(r, origin) || p.Allowed(origin), nil
+			}
+			return p.allowRequest(r, origin, "cors"), nil
+		},
+		AllowCredentials: true,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization", logging.RequestIDHeader},
+	})
+}
+
+// sameOrigin reports whether the origin refers to the host the request was sent to
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,98 @@
+// Package partials turns partial transcripts, which rewrite themselves as
+// the engine hears more audio, into compact diffs against the previous
+// partial
+package partials
+
+import (
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Diff describes a partial as a change to the previous partial of its
+// stream: the first Keep characters are stable and the rest is replaced by
+// Text, the volatile suffix. Keep counts UTF-16 code units, so JavaScript
+// clients can apply a diff with prev.slice(0, keep) + text.
+type Diff struct {
+	Keep int    `json:"keep"`
+	Text string `json:"text"`
+}
+
+// Differ tracks the last partial of each stream of one client, such as the
+// source transcript and each translation language. It is not safe for
+// concurrent use.
+type Differ struct {
+	last map[string]string
+}
+
+// NewDiffer creates a differ without previous partials
+func NewDiffer() *Differ {
+	return &Differ{last: make(map[string]string)}
+}
+
+// Partial returns the diff of a partial against the previous partial of the
+// stream. The stable prefix ends at a word boundary, so a word that is still
+// changing is sent whole.
+func (d *Differ) Partial(stream, text string) Diff {
+	prev := d.last[stream]
+	d.last[stream] = text
+
+	n := commonPrefix(prev, text)
+	n = wordStart(text, n)
+	return Diff{Keep: utf16Len(text[:n]), Text: text[n:]}
+}
+
+// Final ends the partials of a stream. The next partial is diffed against an
+// empty text, as the final replaces the partial on the client. It does
+// nothing on a nil Differ.
+func (d *Differ) Final(stream string) {
+	if d != nil {
+		delete(d.last, stream)
+	}
+}
+
+// commonPrefix returns the byte length of the longest common prefix of a and
+// b that ends at a rune boundary
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) {
+		ra, size := utf8.DecodeRuneInString(a[n:])
+		rb, _ := utf8.DecodeRuneInString(b[n:])
+		if ra != rb {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// wordStart moves a cut at byte n of text back to the start of the word it
+// splits. Scripts written without spaces are cut between any characters.
+func wordStart(text string, n int) int {
+	for n > 0 && n < len(text) {
+		before, size := utf8.DecodeLastRuneInString(text[:n])
+		after, _ := utf8.DecodeRuneInString(text[n:])
+		if !inWord(before) || !inWord(after) {
+			break
+		}
+		n -= size
+	}
+	return n
+}
+
+// inWord reports whether r is part of a space separated word
+func inWord(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-'
+}
+
+// utf16Len returns the length of s in UTF-16 code units
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
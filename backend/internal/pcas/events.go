@@ -0,0 +1,287 @@
+package pcas
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// publishMethod is the PCAS event bus RPC that accepts events
+const publishMethod = "/pcas.bus.v1.EventBusService/Publish"
+
+// Field numbers of pcas.events.v1.Event, from pcas/events/v1/events.proto
+// of github.com/soaringjerry/pcas. Events are encoded by hand, like the raw
+// transcription stream, so that the provider does not depend on the PCAS
+// SDK; TestBusEventWireFormat decodes them with a descriptor of that file.
+const (
+	eventFieldID          = 1
+	eventFieldSource      = 2
+	eventFieldSpecversion = 3
+	eventFieldType        = 4
+	eventFieldSubject     = 5
+	eventFieldTime        = 6
+	eventFieldData        = 7
+	eventFieldUserID      = 8
+)
+
+// SegmentEvent is the data of finalized segment events
+type SegmentEvent struct {
+	SessionID    string  `json:"session_id"`
+	Language     string  `json:"language,omitempty"`
+	Speaker      string  `json:"speaker,omitempty"`
+	SpeakerLabel string  `json:"speaker_label,omitempty"`
+	Text         string  `json:"text"`
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+}
+
+// SummaryEvent is the data of session summary events
+type SummaryEvent struct {
+	SessionID string `json:"session_id"`
+	*sessions.MeetingSummary
+}
+
+// busEvent is a pcas.events.v1.Event
+type busEvent struct {
+	ID      string
+	Type    string
+	Source  string
+	Subject string
+	Time    time.Time
+	Data    *anypb.Any
+	UserID  string
+}
+
+// marshal encodes the event in the protobuf wire format
+func (e *busEvent) marshal() ([]byte, error) {
+	ts, err := proto.Marshal(timestamppb.New(e.Time))
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = appendString(b, eventFieldID, e.ID)
+	b = appendString(b, eventFieldSource, e.Source)
+	b = appendString(b, eventFieldSpecversion, "1.0")
+	b = appendString(b, eventFieldType, e.Type)
+	b = appendString(b, eventFieldSubject, e.Subject)
+	b = protowire.AppendTag(b, eventFieldTime, protowire.BytesType)
+	b = protowire.AppendBytes(b, ts)
+	b = protowire.AppendTag(b, eventFieldData, protowire.BytesType)
+	b = protowire.AppendBytes(b, data)
+	b = appendString(b, eventFieldUserID, e.UserID)
+	return b, nil
+}
+
+// appendString appends a string field, omitting empty strings like proto3
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// rawMessage is a protobuf message that is already encoded
+type rawMessage []byte
+
+// rawCodec sends and receives rawMessages unchanged
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *m, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*m = append((*m)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// EventBus publishes finalized segments and session summaries to the PCAS
+// event bus, so that other D-Apps can subscribe to them without joining the
+// transcription stream. Events are queued and published in the background
+// by Run. A nil EventBus drops every event.
+type EventBus struct {
+	cfg    config.EventBusConfig
+	conn   *grpc.ClientConn
+	queue  chan *busEvent
+	logger *slog.Logger
+}
+
+// NewEventBus creates a publisher for the configured PCAS endpoint. It
+// returns nil when no address is configured.
+func NewEventBus(cfg config.EventBusConfig) (*EventBus, error) {
+	if cfg.Address == "" {
+		return nil, nil
+	}
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if cfg.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(cfg.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PCAS client: %w", err)
+	}
+	return &EventBus{
+		cfg:    cfg,
+		conn:   conn,
+		queue:  make(chan *busEvent, cfg.QueueSize),
+		logger: logging.For("pcas-events"),
+	}, nil
+}
+
+// PublishSegment queues a finalized segment event. The segment text is the
+// subject of the event.
+func (b *EventBus) PublishSegment(ctx context.Context, userID string, seg SegmentEvent) {
+	if b == nil {
+		return
+	}
+	b.publish(ctx, b.cfg.SegmentType, seg.Text, userID, seg)
+}
+
+// PublishSummary queues a session summary event. The summary text is the
+// subject of the event.
+func (b *EventBus) PublishSummary(ctx context.Context, userID string, sum SummaryEvent) {
+	if b == nil || sum.MeetingSummary == nil {
+		return
+	}
+	b.publish(ctx, b.cfg.SummaryType, sum.Summary, userID, sum)
+}
+
+// publish queues an event of type typ with data as a google.protobuf.Struct.
+// Events are dropped when typ is empty or the queue is full.
+func (b *EventBus) publish(ctx context.Context, typ, subject, userID string, data interface{}) {
+	if typ == "" {
+		return
+	}
+	value, err := toStruct(data)
+	if err != nil {
+		b.logger.ErrorContext(ctx, "Failed to encode event", "event_type", typ, "error", err)
+		return
+	}
+	if userID == "" {
+		userID = b.cfg.UserID
+	}
+	ev := &busEvent{
+		ID:      logging.NewID(),
+		Type:    typ,
+		Source:  b.cfg.Source,
+		Subject: subject,
+		Time:    time.Now().UTC(),
+		Data:    value,
+		UserID:  userID,
+	}
+	select {
+	case b.queue <- ev:
+	default:
+		metrics.PCASEvents.WithLabelValues(typ, "dropped").Inc()
+		b.logger.WarnContext(ctx, "PCAS event queue full, dropping event", "event_type", typ)
+	}
+}
+
+// Run publishes queued events until ctx is done, then tries to publish the
+// events still queued before returning
+func (b *EventBus) Run(ctx context.Context) {
+	if b == nil {
+		return
+	}
+	// Sends are bounded by the timeout, so that shutting down does not
+	// cancel the event being published
+	sendCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		select {
+		case ev := <-b.queue:
+			b.send(sendCtx, ev)
+		case <-ctx.Done():
+		}
+	}
+	for {
+		select {
+		case ev := <-b.queue:
+			if err := b.send(sendCtx, ev); err != nil {
+				// The bus is unreachable; do not wait out the timeout for every event
+				b.logger.Warn("Dropping unpublished PCAS events", "events", len(b.queue))
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// send publishes one event. Failures are logged and not retried.
+func (b *EventBus) send(ctx context.Context, ev *busEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.Timeout)
+	defer cancel()
+	body, err := ev.marshal()
+	if err == nil {
+		req, resp := rawMessage(body), rawMessage(nil)
+		err = b.conn.Invoke(ctx, publishMethod, &req, &resp, grpc.ForceCodec(rawCodec{}))
+	}
+	metrics.PCASEvents.WithLabelValues(ev.Type, metrics.Outcome(err)).Inc()
+	if err != nil {
+		b.logger.ErrorContext(ctx, "Failed to publish PCAS event", "event_type", ev.Type, "event_id", ev.ID, "error", err)
+	}
+	return err
+}
+
+// Close closes the connection to PCAS
+func (b *EventBus) Close() error {
+	if b == nil {
+		return nil
+	}
+	return b.conn.Close()
+}
+
+// toStruct converts the JSON encoding of v to a google.protobuf.Struct
+// wrapped in Any
+func toStruct(v interface{}) (*anypb.Any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	return anypb.New(s)
+}
@@ -0,0 +1,133 @@
+package pcas
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventDescriptor describes pcas.events.v1.Event as declared in
+// pcas/events/v1/events.proto of github.com/soaringjerry/pcas. Keep it in
+// sync with that file when the PCAS version changes.
+func eventDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("pcas/events/v1/events.proto"),
+		Package:    proto.String("pcas.events.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/any.proto", "google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Event"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, str, ""),
+				field("source", 2, str, ""),
+				field("specversion", 3, str, ""),
+				field("type", 4, str, ""),
+				field("subject", 5, str, ""),
+				field("time", 6, msg, ".google.protobuf.Timestamp"),
+				field("data", 7, msg, ".google.protobuf.Any"),
+				field("userid", 8, str, ""),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("invalid Event descriptor: %v", err)
+	}
+	return fd.Messages().ByName("Event")
+}
+
+func TestBusEventWireFormat(t *testing.T) {
+	data, err := toStruct(SegmentEvent{SessionID: "s1", Text: "hello", EndTime: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &busEvent{
+		ID:      "event-1",
+		Type:    "dreamtrans.segment.v1",
+		Source:  "/d-app/dreamtrans",
+		Subject: "hello",
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+		Data:    data,
+		UserID:  "user-1",
+	}
+	b, err := ev.marshal()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	md := eventDescriptor(t)
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, m); err != nil {
+		t.Fatalf("event does not decode as pcas.events.v1.Event: %v", err)
+	}
+	if unknown := m.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("event has %d bytes of unknown fields", len(unknown))
+	}
+
+	for name, want := range map[protoreflect.Name]string{
+		"id":          ev.ID,
+		"source":      ev.Source,
+		"specversion": "1.0",
+		"type":        ev.Type,
+		"subject":     ev.Subject,
+		"userid":      ev.UserID,
+	} {
+		if got := m.Get(md.Fields().ByName(name)).String(); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	ts := &timestamppb.Timestamp{}
+	if err := remarshal(m.Get(md.Fields().ByName("time")).Message().Interface(), ts); err != nil {
+		t.Fatal(err)
+	}
+	if !ts.AsTime().Equal(ev.Time) {
+		t.Errorf("time = %v, want %v", ts.AsTime(), ev.Time)
+	}
+
+	anyData := &anypb.Any{}
+	if err := remarshal(m.Get(md.Fields().ByName("data")).Message().Interface(), anyData); err != nil {
+		t.Fatal(err)
+	}
+	s := &structpb.Struct{}
+	if err := anyData.UnmarshalTo(s); err != nil {
+		t.Fatalf("data is not a Struct: %v", err)
+	}
+	if got := s.Fields["text"].GetStringValue(); got != "hello" {
+		t.Errorf("data text = %q, want hello", got)
+	}
+}
+
+// remarshal copies the message src into dst through the wire format
+func remarshal(src, dst proto.Message) error {
+	b, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, dst)
+}
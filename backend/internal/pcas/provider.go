@@ -2,67 +2,177 @@ package pcas
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
 
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/partials"
+	"github.com/dreamtrans/backend/internal/recording"
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/speakers"
 	"github.com/dreamtrans/backend/internal/speechmatics"
+	"github.com/dreamtrans/backend/internal/summarize"
+	"github.com/dreamtrans/backend/internal/tracing"
+	"github.com/dreamtrans/backend/internal/utterance"
+	"github.com/dreamtrans/backend/internal/webhooks"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
 // Provider implements the gRPC streaming service for DreamTrans
 type Provider struct {
+	cfg                *config.Manager
+	logger             *slog.Logger
+	tracer             trace.Tracer
 	speechmaticsClient *speechmatics.Client
+	recordings         *recording.Store
+	sessions           *sessions.Store
+	profiles           *speakers.Registry
+	summarizer         summarize.Summarizer
+	alerts             *alerts.Engine
+	notifier           alerts.Notifier
+	webhooks           *webhooks.Dispatcher
+	events             *EventBus
+}
+
+// Option configures optional Provider features
+type Option func(*Provider)
+
+// WithRecordings archives the audio and transcript of every session in store
+func WithRecordings(store *recording.Store) Option {
+	return func(p *Provider) {
+		p.recordings = store
+	}
+}
+
+// WithSessions stores the transcript and translations of every session in store
+func WithSessions(store *sessions.Store) Option {
+	return func(p *Provider) {
+		p.sessions = store
+	}
+}
+
+// WithSpeakerProfiles labels enrolled speakers by name and, together with
+// WithSessions, stores the speaker identifiers of every session for enrollment
+func WithSpeakerProfiles(registry *speakers.Registry) Option {
+	return func(p *Provider) {
+		p.profiles = registry
+	}
+}
+
+// WithSummarizer summarizes every stored session when it ends. It has no
+// effect without WithSessions.
+func WithSummarizer(s summarize.Summarizer) Option {
+	return func(p *Provider) {
+		p.summarizer = s
+	}
+}
+
+// WithAlerts checks final transcripts against the alert rules of engine.
+// Alerts are sent on the stream as "alert" messages and passed to notifier,
+// which may be nil.
+func WithAlerts(engine *alerts.Engine, notifier alerts.Notifier) Option {
+	return func(p *Provider) {
+		p.alerts = engine
+		p.notifier = notifier
+	}
+}
+
+// WithWebhooks emits the lifecycle events of sessions, their finals and
+// their translations to webhook sinks
+func WithWebhooks(d *webhooks.Dispatcher) Option {
+	return func(p *Provider) {
+		p.webhooks = d
+	}
+}
+
+// WithEventBus publishes finalized segments and, together with
+// WithSummarizer, session summaries to the PCAS event bus
+func WithEventBus(b *EventBus) Option {
+	return func(p *Provider) {
+		p.events = b
+	}
 }
 
 // NewProvider creates a new instance of the DreamTrans provider
-func NewProvider() (*Provider, error) {
-	client, err := speechmatics.NewClient()
+func NewProvider(cfg *config.Manager, opts ...Option) (*Provider, error) {
+	client, err := speechmatics.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Speechmatics client: %w", err)
 	}
 
-	return &Provider{
+	p := &Provider{
+		cfg:                cfg,
+		logger:             logging.For("pcas"),
+		tracer:             tracing.Tracer("pcas"),
 		speechmaticsClient: client,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// ActiveSessions returns the number of transcription sessions in progress
+func (p *Provider) ActiveSessions() int {
+	return p.speechmaticsClient.ActiveSessions()
 }
 
 // TranscribeStream handles bidirectional streaming for real-time transcription
 // This is a raw gRPC stream handler that processes bytes directly
 func (p *Provider) TranscribeStream(stream grpc.ServerStream) error {
-	log.Println("DreamTrans Provider: TranscribeStream started.")
-	defer log.Println("DreamTrans Provider: TranscribeStream finished.")
+	ctx := logging.WithSessionID(stream.Context(), streamSessionID(stream.Context()))
+
+	p.logger.InfoContext(ctx, "TranscribeStream started")
+	defer p.logger.InfoContext(ctx, "TranscribeStream finished")
+
+	defaultLanguage := p.cfg.Current().Transcription.Language
 
-	ctx := stream.Context()
-	
-	// Create channels for audio data
+	// Recorder is nil when recording is disabled
+	rec := p.startRecording(ctx)
+	defer func() {
+		if err := rec.Close(); err != nil {
+			p.logger.ErrorContext(ctx, "Failed to finalize recording", "error", err)
+		}
+	}()
+
+	// Create channels for audio data, closed by the receiving goroutine
 	audioChan := make(chan []byte, 100)
-	defer close(audioChan)
-	
+
 	// Channel for configuration
 	configChan := make(chan map[string]string, 1)
-	
+
+	// Speaker renames sent by the client during the session
+	renameChan := make(chan map[string]string, 10)
+
 	// Error channel for goroutines
 	errChan := make(chan error, 2)
-	
+
 	// Start goroutine to receive data from client
 	go func() {
+		defer close(audioChan)
 		firstMessage := true
 		for {
 			// Receive Any message
 			var anyMsg anypb.Any
 			if err := stream.RecvMsg(&anyMsg); err == io.EOF {
-				close(audioChan)
 				return
 			} else if err != nil {
 				errChan <- status.Errorf(codes.Internal, "failed to receive: %v", err)
 				return
 			}
-			
+
 			// First message should contain configuration
 			if firstMessage {
 				// Extract configuration from first message
@@ -70,10 +180,10 @@ func (p *Provider) TranscribeStream(stream grpc.ServerStream) error {
 				if anyMsg.TypeUrl == "config" {
 					// Parse configuration from value
 					config := make(map[string]string)
-					config["language"] = "en" // Default
+					config["language"] = defaultLanguage
 					config["enable_partials"] = "false"
 					config["max_delay"] = "0"
-					
+
 					// Simple parsing: assume value contains "key=value,key=value"
 					configStr := string(anyMsg.Value)
 					if configStr != "" {
@@ -84,7 +194,7 @@ func (p *Provider) TranscribeStream(stream grpc.ServerStream) error {
 							}
 						}
 					}
-					
+
 					select {
 					case configChan <- config:
 					default:
@@ -93,9 +203,26 @@ func (p *Provider) TranscribeStream(stream grpc.ServerStream) error {
 					continue
 				}
 			}
-			
+
+			// Renames speakers for all following events, e.g. "S1=Alice,S2=Bob"
+			if anyMsg.TypeUrl == "speakers" {
+				names := make(map[string]string)
+				for _, pair := range splitConfig(string(anyMsg.Value)) {
+					if k, v, ok := parseKeyValue(pair); ok {
+						names[k] = v
+					}
+				}
+				select {
+				case renameChan <- names:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
 			// All other messages are audio data
 			if len(anyMsg.Value) > 0 {
+				rec.WriteAudio(anyMsg.Value)
 				select {
 				case audioChan <- anyMsg.Value:
 				case <-ctx.Done():
@@ -104,72 +231,225 @@ func (p *Provider) TranscribeStream(stream grpc.ServerStream) error {
 			}
 		}
 	}()
-	
+
 	// Wait for configuration
 	var config map[string]string
 	select {
 	case config = <-configChan:
-		log.Printf("Received config: %v", config)
+		p.logger.InfoContext(ctx, "Received config", "language", config["language"], "enable_partials", config["enable_partials"], "max_delay", config["max_delay"])
 	case <-ctx.Done():
 		return ctx.Err()
 	case err := <-errChan:
 		return err
 	}
-	
+
+	// Continue the caller's trace when the attributes carry W3C trace context
+	ctx, span := p.startSessionSpan(ctx, config)
+	defer span.End()
+
 	// Extract configuration
 	language := config["language"]
 	if language == "" {
-		language = "en"
+		language = defaultLanguage
+	}
+	rec.SetLanguage(language)
+
+	// Initial speaker names, e.g. "speakers=S1:Alice|S2:Bob"
+	speakerNames, err := sessions.MergeSpeakers(nil, parseSpeakers(config["speakers"]))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid speakers: %v", err)
+	}
+	speakerLabels := config["speaker_labels"] == "true"
+
+	// "utterances=true" also sends finals assembled into complete
+	// utterances; the engine's end of utterance silence can be set with
+	// "end_of_utterance_silence"
+	utterances := config["utterances"] == "true"
+	eouSilence := 0.0
+	if utterances {
+		eouSilence, err = parseSilence(config["end_of_utterance_silence"], p.cfg.Current().Transcription.EndOfUtteranceSilence)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid end_of_utterance_silence: %v", err)
+		}
+	}
+
+	// Writer is nil when session storage is disabled
+	stored := p.startStoredSession(ctx, language, config, rec.ID(), speakerNames)
+	sessionID := stored.ID()
+	if sessionID == "" {
+		sessionID = logging.SessionID(ctx)
+	}
+	webhookSession := webhooks.Session{
+		Source:   sessions.SourceRealtime,
+		Title:    config["title"],
+		Language: language,
+		Tags:     splitList(config["tags"]),
 	}
-	
+	p.webhooks.Emit(ctx, webhooks.EventSessionStarted, sessionID, webhookSession)
+	// "user_id" sets the PCAS user of published events
+	userID := config["user_id"]
+	defer func() {
+		p.webhooks.Emit(ctx, webhooks.EventSessionEnded, sessionID, webhookSession)
+		if err := stored.Close(); err != nil {
+			p.logger.ErrorContext(ctx, "Failed to store session", "error", err)
+		} else if stored != nil && p.summarizer != nil {
+			go p.summarizeSession(context.WithoutCancel(ctx), stored.ID(), userID)
+		}
+	}()
+
 	enablePartials := config["enable_partials"] == "true"
 	maxDelay := 0.0
 	if delayStr := config["max_delay"]; delayStr != "" {
 		fmt.Sscanf(delayStr, "%f", &maxDelay)
 	}
-	
+
+	// "partial_diff=true" sends partials as diffs against the previous
+	// partial ("partial_diff" and "partial_diff/<language>") instead of
+	// "[PARTIAL] " prefixed text
+	var differ *partials.Differ
+	if config["partial_diff"] == "true" {
+		differ = partials.NewDiffer()
+	}
+
+	var segmenter *utterance.Segmenter
+	if utterances {
+		segmenter = utterance.NewSegmenter()
+	}
+
+	// Alert rules keep their cooldowns per session
+	matcher := p.alerts.Matcher()
+
 	// Configure streaming transcription
 	streamConfig := speechmatics.StreamingConfig{
-		Language:       language,
-		EnablePartials: enablePartials,
-		MaxDelay:       maxDelay,
-	}
-	
-	// Create text channel to receive transcription results
-	textChan := make(chan string)
-	
-	// Start Speechmatics streaming transcription
+		Language:        language,
+		EnablePartials:  enablePartials,
+		MaxDelay:        maxDelay,
+		TargetLanguages: splitList(config["target_languages"]),
+		KnownSpeakers:   p.knownSpeakers(ctx),
+		// Identifiers are only useful if they can be enrolled later
+		GetSpeakers:           p.profiles != nil && stored != nil,
+		EndOfUtteranceSilence: eouSilence,
+	}
+
+	// Create event channel to receive transcription results
+	events := make(chan speechmatics.TranscriptEvent)
+
+	// Start Speechmatics streaming transcription. events is closed before
+	// the error is returned, so the result is delivered on its own channel.
+	transcriptionDone := make(chan error, 1)
 	go func() {
-		err := p.speechmaticsClient.StartStreamingTranscription(ctx, streamConfig, audioChan, textChan)
-		if err != nil {
-			errChan <- fmt.Errorf("speechmatics error: %w", err)
-		}
+		transcriptionDone <- p.speechmaticsClient.StartStreamingTranscription(ctx, streamConfig, audioChan, events)
 	}()
-	
+
 	// Forward transcription results to client
 	for {
 		select {
-		case text, ok := <-textChan:
+		case ev, ok := <-events:
 			if !ok {
-				return nil
+				if err := <-transcriptionDone; err != nil {
+					return status.Errorf(codes.Unavailable, "speechmatics error: %v", err)
+				}
+				return sendUtterances(stream, segmenter.Flush(), speakerNames, speakerLabels)
+			}
+
+			if len(ev.Speakers) > 0 {
+				identifiers := make(map[string][]string, len(ev.Speakers))
+				for _, sp := range ev.Speakers {
+					identifiers[sp.Label] = sp.Identifiers
+				}
+				stored.Update(func(s *sessions.Session) {
+					s.SpeakerIdentifiers = identifiers
+				})
+				p.logger.InfoContext(ctx, "Stored speaker identifiers", "speakers", len(identifiers))
+				continue
+			}
+			if ev.EndOfUtterance {
+				if err := sendUtterances(stream, segmenter.EndOfUtterance(), speakerNames, speakerLabels); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// The stored session holds the current names, including those
+			// set through the sessions API
+			if names, ok := stored.Speakers(); ok {
+				speakerNames = names
+			}
+
+			text, typeURL := ev.Text, "transcription"
+			if speakerLabels && ev.Speaker != "" {
+				text = speakerName(speakerNames, ev.Speaker) + ": " + text
+			}
+			if ev.IsTranslation() {
+				typeURL = "translation/" + ev.Language
 			}
-			
+			value := []byte(text)
+			switch {
+			case !ev.Final && differ != nil:
+				// {"keep":n,"text":"..."} against the previous partial
+				typeURL = "partial_diff"
+				if ev.IsTranslation() {
+					typeURL += "/" + ev.Language
+				}
+				data, err := json.Marshal(differ.Partial(ev.Language, text))
+				if err != nil {
+					return status.Errorf(codes.Internal, "failed to encode partial: %v", err)
+				}
+				value = data
+			case !ev.Final:
+				// Prefix with [PARTIAL] to distinguish from final transcripts
+				value = []byte("[PARTIAL] " + text)
+			case ev.IsTranslation():
+				differ.Final(ev.Language)
+				stored.AddTranslation(sessions.Translation{Language: ev.Language, Speaker: ev.Speaker, Text: ev.Text, StartTime: ev.StartTime, EndTime: ev.EndTime})
+				p.webhooks.Emit(ctx, webhooks.EventTranslation, sessionID, webhookSegment(ev, speakerNames))
+			default:
+				differ.Final(ev.Language)
+				rec.AddSegment(recording.Segment{Text: ev.Text, Speaker: ev.Speaker, StartTime: ev.StartTime, EndTime: ev.EndTime})
+				stored.AddSegment(sessions.Segment{Speaker: ev.Speaker, Text: ev.Text, StartTime: ev.StartTime, EndTime: ev.EndTime})
+				p.webhooks.Emit(ctx, webhooks.EventSegmentFinal, sessionID, webhookSegment(ev, speakerNames))
+				webhookSession.Segments++
+				p.events.PublishSegment(ctx, userID, segmentEvent(ev, speakerNames, sessionID, language))
+			}
+
 			// Send text as Any message
 			anyResp := &anypb.Any{
-				TypeUrl: "transcription",
-				Value:   []byte(text),
+				TypeUrl: typeURL,
+				Value:   value,
 			}
-			
+
 			if err := stream.SendMsg(anyResp); err != nil {
 				return status.Errorf(codes.Internal, "failed to send: %v", err)
 			}
-			log.Printf("Sent transcription: %s", text)
-			
+			// Transcript text is user speech and is never logged above debug level
+			p.logger.DebugContext(ctx, "Sent transcription", "chars", len(text))
+
+			if ev.Final && !ev.IsTranslation() {
+				if err := p.sendAlerts(ctx, stream, matcher, ev, speakerName(speakerNames, ev.Speaker), sessionID); err != nil {
+					return err
+				}
+				if err := sendUtterances(stream, segmenter.Add(ev.Speaker, ev.Text, ev.StartTime, ev.EndTime), speakerNames, speakerLabels); err != nil {
+					return err
+				}
+			}
+
+		case names := <-renameChan:
+			merged, err := sessions.MergeSpeakers(speakerNames, names)
+			if err != nil {
+				p.logger.WarnContext(ctx, "Ignoring invalid speaker rename", "error", err)
+				continue
+			}
+			speakerNames = merged
+			if err := stored.RenameSpeakers(names); err != nil {
+				p.logger.WarnContext(ctx, "Failed to store speaker names", "error", err)
+			}
+			p.logger.InfoContext(ctx, "Speakers renamed", "speakers", len(names))
+
 		case err := <-errChan:
 			if err != nil {
 				return err
 			}
-			
+
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -185,8 +465,8 @@ func (p *Provider) RegisterService(s *grpc.Server) {
 		Methods:     []grpc.MethodDesc{},
 		Streams: []grpc.StreamDesc{
 			{
-				StreamName:    "TranscribeStream",
-				Handler:       func(srv interface{}, stream grpc.ServerStream) error {
+				StreamName: "TranscribeStream",
+				Handler: func(srv interface{}, stream grpc.ServerStream) error {
 					return p.TranscribeStream(stream)
 				},
 				ServerStreams: true,
@@ -196,6 +476,106 @@ func (p *Provider) RegisterService(s *grpc.Server) {
 	}, p)
 }
 
+// startSessionSpan starts the span covering a transcription session. When the
+// PCAS attributes carry "traceparent"/"tracestate", the span continues that
+// trace and links back to the gRPC stream span.
+func (p *Provider) startSessionSpan(ctx context.Context, attributes map[string]string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer)}
+
+	remote := tracing.ExtractMap(ctx, attributes)
+	if sc := trace.SpanContextFromContext(remote); sc.IsValid() && sc.TraceID() != trace.SpanContextFromContext(ctx).TraceID() {
+		opts = append(opts, trace.WithLinks(trace.LinkFromContext(ctx)))
+		ctx = remote
+	}
+
+	return p.tracer.Start(ctx, "pcas.TranscriptionSession", opts...)
+}
+
+// startRecording starts archiving the session if recording is enabled. It
+// returns nil when recording is disabled or could not be started, which the
+// Recorder methods treat as a no-op.
+func (p *Provider) startRecording(ctx context.Context) *recording.Recorder {
+	if p.recordings == nil {
+		return nil
+	}
+	rec, err := p.recordings.Start(ctx, logging.SessionID(ctx), p.cfg.Current().Transcription.SampleRate)
+	if err != nil {
+		// A broken archive must not stop live transcription
+		p.logger.ErrorContext(ctx, "Failed to start recording", "error", err)
+		return nil
+	}
+	return rec
+}
+
+// knownSpeakers returns the enrolled speakers to identify, or nil when
+// speaker profiles are disabled
+func (p *Provider) knownSpeakers(ctx context.Context) []speechmatics.SpeakerIdentifiers {
+	if p.profiles == nil {
+		return nil
+	}
+	known, err := p.profiles.Known()
+	if err != nil {
+		// Fall back to plain diarization
+		p.logger.ErrorContext(ctx, "Failed to load speaker profiles", "error", err)
+		return nil
+	}
+	return known
+}
+
+// startStoredSession stores the session if session storage is enabled. The
+// stored session uses the session ID, so logs, traces and the stored
+// transcript share one ID. Like startRecording it returns nil on failure.
+func (p *Provider) startStoredSession(ctx context.Context, language string, attributes map[string]string, recordingID string, speakerNames map[string]string) *sessions.Writer {
+	if p.sessions == nil {
+		return nil
+	}
+	s := &sessions.Session{
+		ID:          logging.SessionID(ctx),
+		Title:       attributes["title"],
+		Source:      sessions.SourceRealtime,
+		Language:    language,
+		RecordingID: recordingID,
+		Tags:        splitList(attributes["tags"]),
+		Speakers:    speakerNames,
+	}
+	w, err := p.sessions.Start(ctx, s)
+	if errors.Is(err, sessions.ErrExists) {
+		// The caller reused a request ID; store under a fresh ID instead
+		s.ID = logging.NewID()
+		w, err = p.sessions.Start(ctx, s)
+	}
+	if err != nil {
+		p.logger.ErrorContext(ctx, "Failed to start stored session", "error", err)
+		return nil
+	}
+	return w
+}
+
+// summarizeSession summarizes the stored session id once it has ended and
+// publishes the summary for userID
+func (p *Provider) summarizeSession(ctx context.Context, id, userID string) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Current().Summary.Timeout)
+	defer cancel()
+	sum, err := summarize.Session(ctx, p.summarizer, p.sessions, id)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "Failed to summarize session", "stored_session_id", id, "error", err)
+		return
+	}
+	p.logger.InfoContext(ctx, "Session summarized", "stored_session_id", id, "decisions", len(sum.Decisions), "action_items", len(sum.ActionItems))
+	p.events.PublishSummary(ctx, userID, SummaryEvent{SessionID: id, MeetingSummary: sum})
+}
+
+// streamSessionID returns the caller-supplied request ID from gRPC metadata,
+// or a new random ID
+func streamSessionID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(strings.ToLower(logging.RequestIDHeader)); len(ids) > 0 && sessions.ValidID.MatchString(ids[0]) {
+			return ids[0]
+		}
+	}
+	return logging.NewID()
+}
+
 // Helper functions
 func splitConfig(s string) []string {
 	var result []string
@@ -216,6 +596,125 @@ func splitConfig(s string) []string {
 	return result
 }
 
+// splitList splits a "|" separated attribute value such as "cmn|de"
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseSpeakers parses a "|" separated list of label:name pairs such as
+// "S1:Alice|S2:Bob"
+func parseSpeakers(s string) map[string]string {
+	names := make(map[string]string)
+	for _, item := range splitList(s) {
+		if label, name, ok := strings.Cut(item, ":"); ok {
+			names[label] = name
+		}
+	}
+	return names
+}
+
+// parseSilence parses an end of utterance silence in seconds, which
+// Speechmatics accepts between 0 and 2. An empty value is def.
+func parseSilence(s string, def float64) (float64, error) {
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number of seconds", s)
+	}
+	if !(v >= 0 && v <= 2) {
+		return 0, fmt.Errorf("%q is not between 0 and 2 seconds", s)
+	}
+	return v, nil
+}
+
+// sendUtterances sends completed utterances as "utterance" messages, with
+// the same speaker prefix as transcriptions
+func sendUtterances(stream grpc.ServerStream, utterances []utterance.Utterance, names map[string]string, labels bool) error {
+	for _, u := range utterances {
+		text := u.Text
+		if labels && u.Speaker != "" {
+			text = speakerName(names, u.Speaker) + ": " + text
+		}
+		if err := stream.SendMsg(&anypb.Any{TypeUrl: "utterance", Value: []byte(text)}); err != nil {
+			return status.Errorf(codes.Internal, "failed to send: %v", err)
+		}
+	}
+	return nil
+}
+
+// sendAlerts sends an "alert" message with the JSON encoded alert for each
+// rule the final event matches
+func (p *Provider) sendAlerts(ctx context.Context, stream grpc.ServerStream, matcher *alerts.Matcher, ev speechmatics.TranscriptEvent, speaker, sessionID string) error {
+	for _, a := range matcher.Match(alerts.Segment{
+		Speaker:      speaker,
+		SpeakerLabel: ev.Speaker,
+		Text:         ev.Text,
+		StartTime:    ev.StartTime,
+		EndTime:      ev.EndTime,
+	}) {
+		a.SessionID = sessionID
+		data, err := json.Marshal(a)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode alert: %v", err)
+		}
+		if err := stream.SendMsg(&anypb.Any{TypeUrl: "alert", Value: data}); err != nil {
+			return status.Errorf(codes.Internal, "failed to send: %v", err)
+		}
+		if p.notifier != nil {
+			p.notifier.Notify(ctx, a)
+		}
+		p.logger.InfoContext(ctx, "Alert raised", "rule", a.Rule)
+	}
+	return nil
+}
+
+// webhookSegment returns the webhook event data of a final or translation
+func webhookSegment(ev speechmatics.TranscriptEvent, names map[string]string) webhooks.Segment {
+	seg := webhooks.Segment{
+		SpeakerLabel: ev.Speaker,
+		Text:         ev.Text,
+		StartTime:    ev.StartTime,
+		EndTime:      ev.EndTime,
+	}
+	if ev.IsTranslation() {
+		seg.Language = ev.Language
+	}
+	if ev.Speaker != "" {
+		seg.Speaker = speakerName(names, ev.Speaker)
+	}
+	return seg
+}
+
+// segmentEvent returns the PCAS event data of a final
+func segmentEvent(ev speechmatics.TranscriptEvent, names map[string]string, sessionID, language string) SegmentEvent {
+	seg := webhookSegment(ev, names)
+	return SegmentEvent{
+		SessionID:    sessionID,
+		Language:     language,
+		Speaker:      seg.Speaker,
+		SpeakerLabel: seg.SpeakerLabel,
+		Text:         seg.Text,
+		StartTime:    seg.StartTime,
+		EndTime:      seg.EndTime,
+	}
+}
+
+// speakerName returns the name of a speaker label, or the label itself
+func speakerName(names map[string]string, label string) string {
+	if name, ok := names[label]; ok {
+		return name
+	}
+	return label
+}
+
 func parseKeyValue(s string) (string, string, bool) {
 	for i, ch := range s {
 		if ch == '=' {
@@ -223,4 +722,4 @@ func parseKeyValue(s string) (string, string, bool) {
 		}
 	}
 	return "", "", false
-}
\ No newline at end of file
+}
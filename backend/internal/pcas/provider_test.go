@@ -0,0 +1,30 @@
+package pcas
+
+import "testing"
+
+func TestParseSilence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"", 0.8, true},
+		{"0", 0, true},
+		{"0.5", 0.5, true},
+		{"2", 2, true},
+		{"2.5", 0, false},
+		{"-1", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"half", 0, false},
+		{"0.5s", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSilence(tt.in, 0.8)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("parseSilence(%q) = %v, %v, want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// flacBlockSize is the number of samples per FLAC frame
+const flacBlockSize = 4096
+
+// audioWriter encodes mono 16-bit samples to a file
+type audioWriter interface {
+	WriteSamples(samples []int16) error
+	Close() error
+}
+
+// newAudioWriter creates an encoder for the given format ("wav" or "flac")
+func newAudioWriter(f *os.File, format string, sampleRate int) (audioWriter, error) {
+	switch format {
+	case "wav":
+		return newWAVWriter(f, sampleRate)
+	case "flac":
+		return newFLACWriter(f, sampleRate)
+	default:
+		return nil, fmt.Errorf("unsupported recording format %q", format)
+	}
+}
+
+// float32ToPCM16 converts pcm_f32le audio, as sent to Speechmatics, to 16-bit samples
+func float32ToPCM16(data []byte) []int16 {
+	samples := make([]int16, len(data)/4)
+	for i := range samples {
+		v := math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		v = max(-1, min(1, v))
+		samples[i] = int16(v * math.MaxInt16)
+	}
+	return samples
+}
+
+// wavWriter writes a 16-bit mono PCM WAV file. The header sizes are filled in on Close.
+type wavWriter struct {
+	f          *os.File
+	w          *bufio.Writer
+	sampleRate int
+	dataBytes  uint32
+}
+
+func newWAVWriter(f *os.File, sampleRate int) (*wavWriter, error) {
+	w := &wavWriter{f: f, w: bufio.NewWriter(f), sampleRate: sampleRate}
+	if err := w.writeHeader(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *wavWriter) writeHeader() error {
+	sampleRate := w.sampleRate
+	const channels, bitsPerSample = 1, 16
+	blockAlign := channels * bitsPerSample / 8
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + w.dataBytes, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(channels),
+		uint32(sampleRate), uint32(sampleRate * blockAlign), uint16(blockAlign), uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'}, w.dataBytes,
+	}
+	for _, v := range header {
+		if err := binary.Write(w.w, binary.LittleEndian, v); err != nil {
+			return fmt.Errorf("failed to write WAV header: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *wavWriter) WriteSamples(samples []int16) error {
+	if err := binary.Write(w.w, binary.LittleEndian, samples); err != nil {
+		return err
+	}
+	w.dataBytes += uint32(len(samples) * 2)
+	return nil
+}
+
+func (w *wavWriter) Close() error {
+	err := w.finish()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// finish flushes the samples and rewrites the header now that the data size is known
+func (w *wavWriter) finish() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// flacWriter writes a 16-bit mono FLAC file in fixed-size blocks
+type flacWriter struct {
+	enc        *flac.Encoder
+	sampleRate int
+	pending    []int32
+}
+
+func newFLACWriter(f *os.File, sampleRate int) (*flacWriter, error) {
+	info := &meta.StreamInfo{
+		BlockSizeMin:  flacBlockSize,
+		BlockSizeMax:  flacBlockSize,
+		SampleRate:    uint32(sampleRate),
+		NChannels:     1,
+		BitsPerSample: 16,
+	}
+	enc, err := flac.NewEncoder(f, info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FLAC encoder: %w", err)
+	}
+	// Frames are handed over as verbatim subframes; the encoder replaces each
+	// with the smallest constant or fixed prediction subframe
+	enc.EnablePredictionAnalysis(true)
+	return &flacWriter{enc: enc, sampleRate: sampleRate}, nil
+}
+
+func (w *flacWriter) WriteSamples(samples []int16) error {
+	for _, s := range samples {
+		w.pending = append(w.pending, int32(s))
+	}
+	for len(w.pending) >= flacBlockSize {
+		if err := w.writeFrame(w.pending[:flacBlockSize]); err != nil {
+			return err
+		}
+		w.pending = append(w.pending[:0], w.pending[flacBlockSize:]...)
+	}
+	return nil
+}
+
+func (w *flacWriter) writeFrame(samples []int32) error {
+	f := &frame.Frame{
+		Header: frame.Header{
+			HasFixedBlockSize: true,
+			BlockSize:         uint16(len(samples)),
+			SampleRate:        uint32(w.sampleRate),
+			Channels:          frame.ChannelsMono,
+			BitsPerSample:     16,
+		},
+		Subframes: []*frame.Subframe{{
+			SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+			Samples:   append([]int32(nil), samples...),
+			NSamples:  len(samples),
+		}},
+	}
+	if err := w.enc.WriteFrame(f); err != nil {
+		return fmt.Errorf("failed to write FLAC frame: %w", err)
+	}
+	return nil
+}
+
+func (w *flacWriter) Close() error {
+	// The last frame of a fixed block size stream may be shorter
+	if len(w.pending) > 0 {
+		if err := w.writeFrame(w.pending); err != nil {
+			w.enc.Close()
+			return err
+		}
+	}
+	return w.enc.Close()
+}
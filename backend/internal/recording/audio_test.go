@@ -0,0 +1,76 @@
+package recording
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewkiz/flac"
+)
+
+func TestFLACCompressesLosslessly(t *testing.T) {
+	const sampleRate = 16000
+	// Two and a half blocks of a 440 Hz tone, so that the last frame is short
+	samples := make([]int16, flacBlockSize*5/2)
+	for i := range samples {
+		samples[i] = int16(8000 * math.Sin(2*math.Pi*440*float64(i)/sampleRate))
+	}
+
+	path := filepath.Join(t.TempDir(), "tone.flac")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := newAudioWriter(f, "flac", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write in uneven chunks, like audio arriving from a client
+	for rest := samples; len(rest) > 0; {
+		n := min(len(rest), 1000)
+		if err := w.WriteSamples(rest[:n]); err != nil {
+			t.Fatalf("WriteSamples error: %v", err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := int64(len(samples) * 2); info.Size() >= raw/2 {
+		t.Errorf("FLAC file is %d bytes for %d bytes of PCM, want it compressed", info.Size(), raw)
+	}
+
+	stream, err := flac.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open FLAC file: %v", err)
+	}
+	defer stream.Close()
+	var decoded []int16
+	for {
+		frame, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to decode FLAC frame: %v", err)
+		}
+		for _, s := range frame.Subframes[0].Samples {
+			decoded = append(decoded, int16(s))
+		}
+	}
+	if len(decoded) != len(samples) {
+		t.Fatalf("decoded %d samples, want %d", len(decoded), len(samples))
+	}
+	for i := range samples {
+		if decoded[i] != samples[i] {
+			t.Fatalf("sample %d = %d, want %d", i, decoded[i], samples[i])
+		}
+	}
+}
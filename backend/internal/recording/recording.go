@@ -0,0 +1,356 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+)
+
+// ErrNotFound is returned when a recording does not exist
+var ErrNotFound = errors.New("recording not found")
+
+// validID matches the IDs generated by logging.NewID and guards against path traversal
+var validID = regexp.MustCompile(`^[0-9a-f]{8,64}$`)
+
+// Segment is a final transcript segment. Times are in seconds from the start
+// of the recording.
+type Segment struct {
+	Text      string  `json:"text"`
+	Speaker   string  `json:"speaker,omitempty"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// Recording describes an archived realtime session. It is stored as JSON next
+// to the audio file.
+type Recording struct {
+	ID         string    `json:"id"`
+	SessionID  string    `json:"session_id"`
+	Language   string    `json:"language,omitempty"`
+	Format     string    `json:"format"`
+	SampleRate int       `json:"sample_rate"`
+	StartedAt  time.Time `json:"started_at"`
+	// EndedAt is nil while the session is still being recorded
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	Duration   float64    `json:"duration_seconds"`
+	AudioFile  string     `json:"audio_file"`
+	AudioBytes int64      `json:"audio_bytes"`
+	Segments   []Segment  `json:"segments,omitempty"`
+}
+
+// InProgress reports whether the session is still being recorded
+func (r *Recording) InProgress() bool {
+	return r.EndedAt == nil
+}
+
+// Store keeps recordings in a directory on disk
+type Store struct {
+	cfg    config.RecordingConfig
+	logger *slog.Logger
+}
+
+// NewStore creates the recordings directory if needed
+func NewStore(cfg config.RecordingConfig) (*Store, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	return &Store{cfg: cfg, logger: logging.For("recording")}, nil
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.cfg.Dir, id+".json")
+}
+
+// List returns all recordings, newest first. Segments are omitted.
+func (s *Store) List() ([]Recording, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recordings directory: %w", err)
+	}
+
+	recordings := make([]Recording, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !validID.MatchString(id) {
+			continue
+		}
+		rec, err := s.Get(id)
+		if err != nil {
+			s.logger.Warn("Skipping unreadable recording", "id", id, "error", err)
+			continue
+		}
+		rec.Segments = nil
+		recordings = append(recordings, *rec)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+// Get returns a recording including its transcript
+func (s *Store) Get(id string) (*Recording, error) {
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(s.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+// Open returns the recording and its audio file. The caller closes the file.
+func (s *Store) Open(id string) (*Recording, *os.File, error) {
+	rec, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(filepath.Join(s.cfg.Dir, rec.AudioFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to open audio: %w", err)
+	}
+	return rec, f, nil
+}
+
+// Delete removes a recording and its audio file
+func (s *Store) Delete(id string) error {
+	rec, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.remove(rec)
+}
+
+func (s *Store) remove(rec *Recording) error {
+	if err := os.Remove(filepath.Join(s.cfg.Dir, rec.AudioFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete audio: %w", err)
+	}
+	if err := os.Remove(s.metaPath(rec.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete recording: %w", err)
+	}
+	return nil
+}
+
+// write stores the recording metadata atomically
+func (s *Store) write(rec *Recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.metaPath(rec.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write recording metadata: %w", err)
+	}
+	return os.Rename(tmp, s.metaPath(rec.ID))
+}
+
+// Prune applies the retention policy and returns the number of recordings
+// deleted. Recordings in progress are never deleted.
+func (s *Store) Prune(now time.Time) (int, error) {
+	recordings, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, rec := range recordings {
+		total += rec.AudioBytes
+	}
+
+	deleted := 0
+	// Oldest first, so that the size limit removes the oldest recordings
+	for i := len(recordings) - 1; i >= 0; i-- {
+		rec := &recordings[i]
+		if rec.InProgress() {
+			continue
+		}
+		expired := s.cfg.MaxAge > 0 && now.Sub(rec.StartedAt) > s.cfg.MaxAge
+		oversize := s.cfg.MaxTotalBytes > 0 && total > s.cfg.MaxTotalBytes
+		if !expired && !oversize {
+			continue
+		}
+		if err := s.remove(rec); err != nil {
+			return deleted, err
+		}
+		total -= rec.AudioBytes
+		deleted++
+	}
+	return deleted, nil
+}
+
+// RunRetention prunes recordings periodically until ctx is canceled
+func (s *Store) RunRetention(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.Prune(time.Now())
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to apply recording retention", "error", err)
+		} else if n > 0 {
+			s.logger.InfoContext(ctx, "Deleted expired recordings", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Recorder writes the audio and final transcript of one session. A nil
+// *Recorder is valid and records nothing, so callers do not need to check
+// whether recording is enabled.
+type Recorder struct {
+	store  *Store
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	rec     Recording
+	audio   audioWriter
+	samples int64
+	err     error
+	closed  bool
+}
+
+// Start begins recording a session
+func (s *Store) Start(ctx context.Context, sessionID string, sampleRate int) (*Recorder, error) {
+	id := logging.NewID()
+	audioFile := id + "." + s.cfg.Format
+
+	f, err := os.OpenFile(filepath.Join(s.cfg.Dir, audioFile), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio file: %w", err)
+	}
+	audio, err := newAudioWriter(f, s.cfg.Format, sampleRate)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	r := &Recorder{
+		store:  s,
+		logger: s.logger,
+		audio:  audio,
+		rec: Recording{
+			ID:         id,
+			SessionID:  sessionID,
+			Format:     s.cfg.Format,
+			SampleRate: sampleRate,
+			StartedAt:  time.Now().UTC(),
+			AudioFile:  audioFile,
+			Segments:   []Segment{},
+		},
+	}
+	if err := s.write(&r.rec); err != nil {
+		audio.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "Recording session", "recording_id", id, "format", s.cfg.Format)
+	return r, nil
+}
+
+// ID returns the recording ID
+func (r *Recorder) ID() string {
+	if r == nil {
+		return ""
+	}
+	return r.rec.ID
+}
+
+// SetLanguage records the session language
+func (r *Recorder) SetLanguage(language string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Language = language
+}
+
+// WriteAudio appends pcm_f32le audio. After a write error the recorder stops
+// writing audio; the error is returned by Close.
+func (r *Recorder) WriteAudio(data []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.closed {
+		return
+	}
+	samples := float32ToPCM16(data)
+	if err := r.audio.WriteSamples(samples); err != nil {
+		r.err = fmt.Errorf("failed to write audio: %w", err)
+		r.logger.Error("Recording failed, audio is no longer archived", "recording_id", r.rec.ID, "error", err)
+		return
+	}
+	r.samples += int64(len(samples))
+}
+
+// AddSegment appends a final transcript segment
+func (r *Recorder) AddSegment(seg Segment) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Segments = append(r.rec.Segments, seg)
+}
+
+// Close finalizes the audio file and writes the transcript. Sessions that
+// never received audio are discarded.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+
+	if err := r.audio.Close(); err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to finalize audio: %w", err)
+	}
+	if r.samples == 0 {
+		return r.store.remove(&r.rec)
+	}
+
+	ended := time.Now().UTC()
+	r.rec.EndedAt = &ended
+	r.rec.Duration = float64(r.samples) / float64(r.rec.SampleRate)
+	if info, err := os.Stat(filepath.Join(r.store.cfg.Dir, r.rec.AudioFile)); err == nil {
+		r.rec.AudioBytes = info.Size()
+	}
+	if err := r.store.write(&r.rec); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
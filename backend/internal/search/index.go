@@ -0,0 +1,323 @@
+package search
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/sessions"
+)
+
+const (
+	// syncInterval is how stale the index may be before a search picks up
+	// sessions written by the provider or changed through the API
+	syncInterval = 2 * time.Second
+	// DefaultLimit is the number of hits returned when the query sets no limit
+	DefaultLimit = 50
+	// MaxLimit caps the number of hits per query
+	MaxLimit = 500
+)
+
+// Kinds of indexed text
+const (
+	KindTranscript  = "transcript"
+	KindTranslation = "translation"
+)
+
+// Query selects hits. All terms of Text must occur in a segment; the other
+// fields are optional filters.
+type Query struct {
+	Text string
+	// Speaker matches the speaker name or label
+	Speaker  string
+	Language string
+	// From and To limit the session start time; zero values are open ends
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// Hit is a matching transcript segment or translation. Speaker is the name
+// assigned in the session, SpeakerLabel the diarization label.
+type Hit struct {
+	SessionID        string    `json:"session_id"`
+	SessionTitle     string    `json:"session_title"`
+	SessionStartedAt time.Time `json:"session_started_at"`
+	Source           string    `json:"source"`
+	Kind             string    `json:"kind"`
+	Language         string    `json:"language,omitempty"`
+	SegmentID        int       `json:"segment_id,omitempty"`
+	Speaker          string    `json:"speaker,omitempty"`
+	SpeakerLabel     string    `json:"speaker_label,omitempty"`
+	Text             string    `json:"text"`
+	StartTime        float64   `json:"start_time"`
+	EndTime          float64   `json:"end_time"`
+	Score            float64   `json:"score"`
+}
+
+// doc is one indexed segment or translation
+type doc struct {
+	hit   Hit
+	terms map[string]int
+}
+
+// indexedSession tracks the documents of a session and the file version they came from
+type indexedSession struct {
+	modified time.Time
+	docs     []int
+}
+
+// Index is an in-memory inverted index over the stored sessions. It is built
+// from the session store at startup and kept current by re-indexing sessions
+// whose files changed.
+type Index struct {
+	store  *sessions.Store
+	logger *slog.Logger
+
+	// syncMu serializes syncs; mu guards the index itself
+	syncMu   sync.Mutex
+	mu       sync.RWMutex
+	docs     map[int]*doc
+	postings map[string]map[int]struct{}
+	sessions map[string]*indexedSession
+	nextDoc  int
+	lastSync time.Time
+}
+
+// NewIndex builds the index from the session store
+func NewIndex(store *sessions.Store) (*Index, error) {
+	idx := &Index{
+		store:    store,
+		logger:   logging.For("search"),
+		docs:     make(map[int]*doc),
+		postings: make(map[string]map[int]struct{}),
+		sessions: make(map[string]*indexedSession),
+	}
+	if err := idx.Sync(); err != nil {
+		return nil, err
+	}
+	idx.logger.Info("Search index built", "sessions", len(idx.sessions), "documents", len(idx.docs))
+	return idx, nil
+}
+
+// Sync re-indexes sessions that changed since the last sync and drops
+// deleted ones. Changed sessions are read and tokenized before the index is
+// locked, so that searches only wait for the postings to be swapped.
+func (idx *Index) Sync() error {
+	idx.syncMu.Lock()
+	defer idx.syncMu.Unlock()
+
+	modified, err := idx.store.Modified()
+	if err != nil {
+		return err
+	}
+
+	idx.mu.RLock()
+	var changed []string
+	for id, mod := range modified {
+		if is, ok := idx.sessions[id]; !ok || !is.modified.Equal(mod) {
+			changed = append(changed, id)
+		}
+	}
+	idx.mu.RUnlock()
+
+	prepared := make(map[string][]*doc, len(changed))
+	for _, id := range changed {
+		s, err := idx.store.Get(id)
+		if err != nil {
+			idx.logger.Warn("Failed to index session", "stored_session_id", id, "error", err)
+			continue
+		}
+		prepared[id] = sessionDocs(s)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for id := range idx.sessions {
+		if _, ok := modified[id]; !ok {
+			idx.remove(id)
+		}
+	}
+	for id, docs := range prepared {
+		idx.remove(id)
+		idx.add(id, modified[id], docs)
+	}
+	idx.lastSync = time.Now()
+	return nil
+}
+
+// sessionDocs returns the documents of a session's segments and translations
+func sessionDocs(s *sessions.Session) []*doc {
+	base := Hit{
+		SessionID:        s.ID,
+		SessionTitle:     s.Title,
+		SessionStartedAt: s.StartedAt,
+		Source:           s.Source,
+	}
+
+	docs := make([]*doc, 0, len(s.Segments)+len(s.Translations))
+	for _, seg := range s.Segments {
+		hit := base
+		hit.Kind = KindTranscript
+		hit.Language = s.Language
+		hit.SegmentID = seg.ID
+		hit.Speaker, hit.SpeakerLabel = s.SpeakerName(seg.Speaker), seg.Speaker
+		hit.Text = seg.Text
+		hit.StartTime, hit.EndTime = seg.StartTime, seg.EndTime
+		docs = append(docs, newDoc(hit))
+	}
+	for _, t := range s.Translations {
+		hit := base
+		hit.Kind = KindTranslation
+		hit.Language = t.Language
+		hit.Speaker, hit.SpeakerLabel = s.SpeakerName(t.Speaker), t.Speaker
+		hit.Text = t.Text
+		hit.StartTime, hit.EndTime = t.StartTime, t.EndTime
+		docs = append(docs, newDoc(hit))
+	}
+	return docs
+}
+
+// newDoc tokenizes the text of a hit
+func newDoc(hit Hit) *doc {
+	d := &doc{hit: hit, terms: make(map[string]int)}
+	for _, term := range tokenize(hit.Text) {
+		d.terms[term]++
+	}
+	return d
+}
+
+// add indexes the documents of a session. The caller holds idx.mu.
+func (idx *Index) add(sessionID string, modified time.Time, docs []*doc) {
+	is := &indexedSession{modified: modified, docs: make([]int, 0, len(docs))}
+	for _, d := range docs {
+		id := idx.nextDoc
+		idx.nextDoc++
+		for term := range d.terms {
+			p, ok := idx.postings[term]
+			if !ok {
+				p = make(map[int]struct{})
+				idx.postings[term] = p
+			}
+			p[id] = struct{}{}
+		}
+		idx.docs[id] = d
+		is.docs = append(is.docs, id)
+	}
+	idx.sessions[sessionID] = is
+}
+
+// remove drops a session from the index. The caller holds idx.mu.
+func (idx *Index) remove(sessionID string) {
+	is, ok := idx.sessions[sessionID]
+	if !ok {
+		return
+	}
+	for _, id := range is.docs {
+		for term := range idx.docs[id].terms {
+			delete(idx.postings[term], id)
+			if len(idx.postings[term]) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+		delete(idx.docs, id)
+	}
+	delete(idx.sessions, sessionID)
+}
+
+// Search returns the best matching segments and translations
+func (idx *Index) Search(q Query) ([]Hit, error) {
+	idx.mu.RLock()
+	stale := time.Since(idx.lastSync) > syncInterval
+	idx.mu.RUnlock()
+	if stale {
+		if err := idx.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	terms := queryTerms(q.Text)
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Walk the rarest term's postings and check the others
+	sort.Slice(terms, func(i, j int) bool {
+		return len(idx.postings[terms[i]]) < len(idx.postings[terms[j]])
+	})
+
+	hits := []Hit{}
+	for id := range idx.postings[terms[0]] {
+		d := idx.docs[id]
+		if !q.matches(d) {
+			continue
+		}
+		score, ok := idx.score(d, terms)
+		if !ok {
+			continue
+		}
+		hit := d.hit
+		hit.Score = math.Round(score*1000) / 1000
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.SessionStartedAt.Equal(b.SessionStartedAt) {
+			return a.SessionStartedAt.After(b.SessionStartedAt)
+		}
+		return a.StartTime < b.StartTime
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// score returns the TF-IDF score of a document, or false if it misses a term.
+// The caller holds idx.mu.
+func (idx *Index) score(d *doc, terms []string) (float64, bool) {
+	n := float64(len(idx.docs))
+	var score float64
+	for _, term := range terms {
+		tf := d.terms[term]
+		if tf == 0 {
+			return 0, false
+		}
+		idf := math.Log(1 + n/float64(len(idx.postings[term])))
+		score += float64(tf) * idf
+	}
+	return score, true
+}
+
+// matches applies the filters of the query
+func (q Query) matches(d *doc) bool {
+	if q.Speaker != "" && !strings.EqualFold(d.hit.Speaker, q.Speaker) && !strings.EqualFold(d.hit.SpeakerLabel, q.Speaker) {
+		return false
+	}
+	if q.Language != "" && !strings.EqualFold(d.hit.Language, q.Language) {
+		return false
+	}
+	if !q.From.IsZero() && d.hit.SessionStartedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && d.hit.SessionStartedAt.After(q.To) {
+		return false
+	}
+	return true
+}
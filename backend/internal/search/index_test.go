@@ -0,0 +1,121 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/sessions"
+)
+
+func newTestIndex(t *testing.T) (*Index, *sessions.Store) {
+	t.Helper()
+	store, err := sessions.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*sessions.Session{
+		{
+			ID:        "monday",
+			Language:  "en",
+			StartedAt: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+			Speakers:  map[string]string{"S1": "Alice"},
+			Segments: []sessions.Segment{
+				{Speaker: "S1", Text: "The database migration starts today."},
+				{Speaker: "S2", Text: "Migration migration, finally."},
+			},
+			Translations: []sessions.Translation{
+				{Language: "cmn", Speaker: "S1", Text: "数据库迁移今天开始。"},
+			},
+		},
+		{
+			ID:        "friday",
+			Language:  "en",
+			StartedAt: time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC),
+			Segments: []sessions.Segment{
+				{Speaker: "S1", Text: "The migration is done."},
+			},
+		},
+	} {
+		if err := store.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := NewIndex(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx, store
+}
+
+func TestSearchFilters(t *testing.T) {
+	idx, _ := newTestIndex(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"all terms", Query{Text: "database migration"}, []string{"The database migration starts today."}},
+		{"ranked by frequency", Query{Text: "migration", Language: "en"}, []string{"Migration migration, finally.", "The migration is done.", "The database migration starts today."}},
+		{"speaker name", Query{Text: "migration", Speaker: "alice"}, []string{"The database migration starts today."}},
+		{"speaker label", Query{Text: "migration", Speaker: "S2"}, []string{"Migration migration, finally."}},
+		{"language", Query{Text: "迁移", Language: "CMN"}, []string{"数据库迁移今天开始。"}},
+		{"CJK bigrams", Query{Text: "迁今"}, nil},
+		{"from", Query{Text: "migration", From: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)}, []string{"The migration is done."}},
+		{"to", Query{Text: "done", To: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)}, nil},
+		{"limit", Query{Text: "migration", Limit: 1}, []string{"Migration migration, finally."}},
+		{"no terms", Query{Text: "?"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := idx.Search(tt.q)
+			if err != nil {
+				t.Fatalf("Search error: %v", err)
+			}
+			var got []string
+			for _, h := range hits {
+				got = append(got, h.Text)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("hits = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("hits = %q, want %q", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSyncPicksUpChanges(t *testing.T) {
+	idx, store := newTestIndex(t)
+	// Changes are detected by file modification time, which some file
+	// systems only advance every few milliseconds
+	time.Sleep(20 * time.Millisecond)
+	if _, err := store.Update("friday", func(s *sessions.Session) error {
+		s.Segments[0].Text = "The rollout is done."
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("monday"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Sync(); err != nil {
+		t.Fatalf("Sync error: %v", err)
+	}
+
+	if hits, _ := idx.Search(Query{Text: "migration"}); len(hits) != 0 {
+		t.Errorf("found %d hits of changed and deleted sessions", len(hits))
+	}
+	if hits, _ := idx.Search(Query{Text: "rollout"}); len(hits) != 1 || hits[0].SessionID != "friday" {
+		t.Errorf("rollout hits = %+v, want the updated session", hits)
+	}
+	idx.mu.RLock()
+	docs, terms := len(idx.docs), len(idx.postings["database"])
+	idx.mu.RUnlock()
+	if docs != 1 || terms != 0 {
+		t.Errorf("index keeps %d documents and %d stale postings", docs, terms)
+	}
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK reports whether r belongs to a script written without spaces between
+// words. Such text is indexed as single characters and character bigrams.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenize splits text into index terms. Words in spaced scripts are
+// lowercased; runs of CJK characters produce every character and every pair
+// of adjacent characters, so that both single-character and multi-character
+// queries match.
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+		prev   rune
+	)
+	flushWord := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			tokens = append(tokens, string(r))
+			if prev != 0 {
+				tokens = append(tokens, string([]rune{prev, r}))
+			}
+			prev = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushWord()
+		}
+		prev = 0
+	}
+	flushWord()
+	return tokens
+}
+
+// queryTerms returns the terms that must all match for a query. CJK runs of
+// two or more characters are matched by their bigrams only, which keeps
+// "迁移" from matching text that merely contains "迁" and "移" apart.
+func queryTerms(query string) []string {
+	var (
+		terms []string
+		run   []rune
+		word  strings.Builder
+	)
+	flushRun := func() {
+		switch len(run) {
+		case 0:
+		case 1:
+			terms = append(terms, string(run))
+		default:
+			for i := 1; i < len(run); i++ {
+				terms = append(terms, string(run[i-1:i+1]))
+			}
+		}
+		run = run[:0]
+	}
+	flushWord := func() {
+		if word.Len() > 0 {
+			terms = append(terms, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case isCJK(r):
+			flushWord()
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushRun()
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushRun()
+			flushWord()
+		}
+	}
+	flushRun()
+	flushWord()
+	return dedupe(terms)
+}
+
+func dedupe(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
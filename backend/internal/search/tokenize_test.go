@@ -0,0 +1,50 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World", []string{"hello", "world"}},
+		{"v2 release", []string{"v2", "release"}},
+		{"数据迁移", []string{"数", "据", "数据", "迁", "据迁", "移", "迁移"}},
+		{"run 迁移 now", []string{"run", "迁", "移", "迁移", "now"}},
+		{"迁。移", []string{"迁", "移"}},
+		{"한국어", []string{"한", "국", "한국", "어", "국어"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tokenize(tt.text); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Release Notes", []string{"release", "notes"}},
+		{"notes notes", []string{"notes"}},
+		{"迁", []string{"迁"}},
+		{"迁移", []string{"迁移"}},
+		{"数据迁移", []string{"数据", "据迁", "迁移"}},
+		{"v2 迁移", []string{"v2", "迁移"}},
+		{"!!", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := queryTerms(tt.query); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("queryTerms(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// Export formats
+const (
+	FormatText = "txt"
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+)
+
+// ExportContentTypes maps export formats to MIME types
+var ExportContentTypes = map[string]string{
+	FormatText: "text/plain; charset=utf-8",
+	FormatSRT:  "application/x-subrip; charset=utf-8",
+	FormatVTT:  "text/vtt; charset=utf-8",
+}
+
+// cue is one line of an export
+type cue struct {
+	Speaker   string
+	Text      string
+	StartTime float64
+	EndTime   float64
+}
+
+// Export renders the current (edited) transcript, or the translation into
+// language when it is not empty, as plain text or subtitles. Named speakers
+// are shown by name.
+func (s *Session) Export(format, language string) ([]byte, error) {
+	var cues []cue
+	if language == "" {
+		for _, seg := range s.Segments {
+			cues = append(cues, cue{Speaker: s.SpeakerName(seg.Speaker), Text: seg.Text, StartTime: seg.StartTime, EndTime: seg.EndTime})
+		}
+	} else {
+		for _, t := range s.Translations {
+			if t.Language == language {
+				cues = append(cues, cue{Speaker: s.SpeakerName(t.Speaker), Text: t.Text, StartTime: t.StartTime, EndTime: t.EndTime})
+			}
+		}
+		if len(cues) == 0 {
+			return nil, fmt.Errorf("no %s translation in session %s", language, s.ID)
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatText:
+		for _, c := range cues {
+			fmt.Fprintf(&buf, "[%s] ", clock(c.StartTime, ":", false))
+			if c.Speaker != "" {
+				fmt.Fprintf(&buf, "%s: ", c.Speaker)
+			}
+			fmt.Fprintln(&buf, c.Text)
+		}
+	case FormatSRT:
+		for i, c := range cues {
+			fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n", i+1, clock(c.StartTime, ",", true), clock(c.EndTime, ",", true), subtitleText(c))
+		}
+	case FormatVTT:
+		buf.WriteString("WEBVTT\n\n")
+		for _, c := range cues {
+			text := c.Text
+			if c.Speaker != "" {
+				// WebVTT voice span
+				text = fmt.Sprintf("<v %s>%s", c.Speaker, c.Text)
+			}
+			fmt.Fprintf(&buf, "%s --> %s\n%s\n\n", clock(c.StartTime, ".", true), clock(c.EndTime, ".", true), text)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// subtitleText prefixes the speaker for SRT, which has no speaker markup
+func subtitleText(c cue) string {
+	if c.Speaker == "" {
+		return c.Text
+	}
+	return c.Speaker + ": " + c.Text
+}
+
+// clock formats seconds as hh:mm:ss, with milliseconds after sep when millis is set
+func clock(seconds float64, sep string, millis bool) string {
+	ms := int64(math.Round(max(seconds, 0) * 1000))
+	h, m, sec := ms/3600000, ms/60000%60, ms/1000%60
+	if !millis {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, sec, sep, ms%1000)
+}
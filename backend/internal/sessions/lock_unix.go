@@ -0,0 +1,27 @@
+//go:build unix
+
+package sessions
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive lock on the lock file of a session, which
+// serializes read-modify-write cycles with other processes sharing the
+// directory. The returned function releases the lock.
+func (st *Store) lock(id string) (func(), error) {
+	f, err := os.OpenFile(st.lockPath(id), os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock session: %w", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,42 @@
+package sessions
+
+import "time"
+
+// MeetingSummary is the summary of a session with the decisions made and the
+// action items agreed on
+type MeetingSummary struct {
+	Summary     string       `json:"summary"`
+	Decisions   []string     `json:"decisions"`
+	ActionItems []ActionItem `json:"action_items"`
+	// Summarizer names what produced the summary, such as "local" or
+	// "speechmatics"
+	Summarizer string    `json:"summarizer"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ActionItem is a task taken on in the session
+type ActionItem struct {
+	Text string `json:"text"`
+	// Speaker is the label of the speaker who owns the item, if known
+	Speaker string `json:"speaker,omitempty"`
+	// Owner is the name of the speaker, set when the summary is presented
+	Owner     string  `json:"owner,omitempty"`
+	StartTime float64 `json:"start_time"`
+}
+
+// PresentSummary returns a copy of the meeting summary with the current
+// speaker names as action item owners, or nil if there is none
+func (s *Session) PresentSummary() *MeetingSummary {
+	if s.MeetingSummary == nil {
+		return nil
+	}
+	sum := *s.MeetingSummary
+	sum.ActionItems = make([]ActionItem, len(s.MeetingSummary.ActionItems))
+	for i, item := range s.MeetingSummary.ActionItems {
+		if item.Speaker != "" {
+			item.Owner = s.SpeakerName(item.Speaker)
+		}
+		sum.ActionItems[i] = item
+	}
+	return &sum
+}
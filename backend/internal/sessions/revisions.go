@@ -0,0 +1,254 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Revision operations
+const (
+	OpEdit   = "edit"
+	OpSplit  = "split"
+	OpMerge  = "merge"
+	OpRevert = "revert"
+)
+
+// ErrInvalidEdit is returned when an edit cannot be applied to the transcript
+var ErrInvalidEdit = errors.New("invalid edit")
+
+// Revision is one change to the transcript. Before holds the segments as they
+// were and After the segments that replaced them, so a revision can be shown
+// as a diff and undone. Segments appended by a live session are not
+// revisions; only edits are tracked.
+type Revision struct {
+	ID        int       `json:"id"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	Operation string    `json:"operation"`
+	Before    []Segment `json:"before"`
+	After     []Segment `json:"after"`
+}
+
+// Change is the difference of one segment between two transcript versions
+type Change struct {
+	SegmentID int      `json:"segment_id"`
+	Type      string   `json:"type"` // added, removed or changed
+	Before    *Segment `json:"before,omitempty"`
+	After     *Segment `json:"after,omitempty"`
+}
+
+func invalidEdit(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidEdit, fmt.Sprintf(format, args...))
+}
+
+// segment returns the segment with the given ID
+func (s *Session) segment(id int) (int, *Segment, error) {
+	for i := range s.Segments {
+		if s.Segments[i].ID == id {
+			return i, &s.Segments[i], nil
+		}
+	}
+	return -1, nil, invalidEdit("segment %d does not exist", id)
+}
+
+// replaceSegments removes the segments with the IDs in remove, adds add and
+// keeps the transcript in time order
+func replaceSegments(segments []Segment, remove, add []Segment) []Segment {
+	drop := make(map[int]bool, len(remove))
+	for _, seg := range remove {
+		drop[seg.ID] = true
+	}
+	out := make([]Segment, 0, len(segments)+len(add))
+	for _, seg := range segments {
+		if !drop[seg.ID] {
+			out = append(out, seg)
+		}
+	}
+	out = append(out, add...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].StartTime != out[j].StartTime {
+			return out[i].StartTime < out[j].StartTime
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// apply records a revision and applies it to the transcript
+func (s *Session) apply(author, op string, before, after []Segment) *Revision {
+	if author == "" {
+		author = "anonymous"
+	}
+	s.Segments = replaceSegments(s.Segments, before, after)
+	s.Revisions = append(s.Revisions, Revision{
+		ID:        len(s.Revisions) + 1,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+		Operation: op,
+		Before:    before,
+		After:     after,
+	})
+	return &s.Revisions[len(s.Revisions)-1]
+}
+
+// EditSegment changes the text and/or the speaker of a segment
+func (s *Session) EditSegment(author string, id int, text, speaker *string) (*Revision, error) {
+	_, seg, err := s.segment(id)
+	if err != nil {
+		return nil, err
+	}
+	edited := *seg
+	if text != nil {
+		if strings.TrimSpace(*text) == "" {
+			return nil, invalidEdit("text must not be empty, delete by merging instead")
+		}
+		edited.Text = strings.TrimSpace(*text)
+	}
+	if speaker != nil {
+		edited.Speaker = strings.TrimSpace(*speaker)
+	}
+	if edited == *seg {
+		return nil, invalidEdit("nothing to change")
+	}
+	return s.apply(author, OpEdit, []Segment{*seg}, []Segment{edited}), nil
+}
+
+// SplitSegment splits a segment at a character offset into its text. The
+// split time is interpolated from the offset unless at is given.
+func (s *Session) SplitSegment(author string, id, offset int, at *float64) (*Revision, error) {
+	_, seg, err := s.segment(id)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(seg.Text)
+	if offset <= 0 || offset >= len(runes) {
+		return nil, invalidEdit("offset must be inside the segment text (1-%d)", len(runes)-1)
+	}
+	first, second := strings.TrimSpace(string(runes[:offset])), strings.TrimSpace(string(runes[offset:]))
+	if first == "" || second == "" {
+		return nil, invalidEdit("both parts of a split must contain text")
+	}
+
+	splitTime := seg.StartTime + (seg.EndTime-seg.StartTime)*float64(offset)/float64(len(runes))
+	if at != nil {
+		if *at <= seg.StartTime || *at >= seg.EndTime {
+			return nil, invalidEdit("split time must be between %.2f and %.2f", seg.StartTime, seg.EndTime)
+		}
+		splitTime = *at
+	}
+
+	a, b := *seg, *seg
+	a.Text, a.EndTime = first, splitTime
+	b.ID, b.Text, b.StartTime = s.nextSegmentID(), second, splitTime
+	return s.apply(author, OpSplit, []Segment{*seg}, []Segment{a, b}), nil
+}
+
+// MergeSegments joins consecutive segments into the first one. The merged
+// segment keeps the speaker of the first segment.
+func (s *Session) MergeSegments(author string, ids []int) (*Revision, error) {
+	if len(ids) < 2 {
+		return nil, invalidEdit("at least two segments are required")
+	}
+	first, _, err := s.segment(ids[0])
+	if err != nil {
+		return nil, err
+	}
+	if first+len(ids) > len(s.Segments) {
+		return nil, invalidEdit("segments must be consecutive")
+	}
+	before := make([]Segment, len(ids))
+	for i, id := range ids {
+		if s.Segments[first+i].ID != id {
+			return nil, invalidEdit("segments must be consecutive and in transcript order")
+		}
+		before[i] = s.Segments[first+i]
+	}
+
+	merged := before[0]
+	for _, seg := range before[1:] {
+		merged.Text = joinText(merged.Text, seg.Text)
+		merged.EndTime = max(merged.EndTime, seg.EndTime)
+	}
+	return s.apply(author, OpMerge, before, []Segment{merged}), nil
+}
+
+// StateAt returns the transcript as it was after revision rev; 0 is the
+// transcript before any edit
+func (s *Session) StateAt(rev int) ([]Segment, error) {
+	if rev < 0 || rev > len(s.Revisions) {
+		return nil, invalidEdit("revision %d does not exist", rev)
+	}
+	segments := append([]Segment(nil), s.Segments...)
+	for i := len(s.Revisions) - 1; i >= rev; i-- {
+		r := s.Revisions[i]
+		segments = replaceSegments(segments, r.After, r.Before)
+	}
+	return segments, nil
+}
+
+// Revert restores the transcript to its state after revision rev. The revert
+// is recorded as a new revision, so it can itself be reverted.
+func (s *Session) Revert(author string, rev int) (*Revision, error) {
+	target, err := s.StateAt(rev)
+	if err != nil {
+		return nil, err
+	}
+	var before, after []Segment
+	for _, c := range Diff(s.Segments, target) {
+		if c.Before != nil {
+			before = append(before, *c.Before)
+		}
+		if c.After != nil {
+			after = append(after, *c.After)
+		}
+	}
+	if len(before) == 0 && len(after) == 0 {
+		return nil, invalidEdit("transcript already matches revision %d", rev)
+	}
+	return s.apply(author, OpRevert, before, after), nil
+}
+
+// Diff compares two versions of a transcript by segment ID
+func Diff(from, to []Segment) []Change {
+	old := make(map[int]Segment, len(from))
+	for _, seg := range from {
+		old[seg.ID] = seg
+	}
+
+	var changes []Change
+	seen := make(map[int]bool, len(to))
+	for _, seg := range to {
+		seg := seg
+		seen[seg.ID] = true
+		prev, ok := old[seg.ID]
+		switch {
+		case !ok:
+			changes = append(changes, Change{SegmentID: seg.ID, Type: "added", After: &seg})
+		case prev != seg:
+			changes = append(changes, Change{SegmentID: seg.ID, Type: "changed", Before: &prev, After: &seg})
+		}
+	}
+	for _, seg := range from {
+		seg := seg
+		if !seen[seg.ID] {
+			changes = append(changes, Change{SegmentID: seg.ID, Type: "removed", Before: &seg})
+		}
+	}
+	return changes
+}
+
+// joinText joins two pieces of transcript. Chinese and Japanese are joined
+// without a space.
+func joinText(a, b string) string {
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if unicode.In(last, unicode.Han, unicode.Hiragana, unicode.Katakana) && unicode.In(first, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return a + b
+	}
+	return a + " " + b
+}
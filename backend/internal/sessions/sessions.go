@@ -0,0 +1,402 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/logging"
+)
+
+// Sources of stored sessions
+const (
+	SourceRealtime = "realtime"
+	SourceBatch    = "batch"
+	SourceImport   = "import"
+	SourceLive     = "live"
+)
+
+var (
+	// ErrNotFound is returned when a session does not exist
+	ErrNotFound = errors.New("session not found")
+	// ErrExists is returned when creating a session with an ID that is already used
+	ErrExists = errors.New("session already exists")
+)
+
+// ValidID matches session IDs. IDs are used as file names, so anything else is
+// rejected to guard against path traversal.
+var ValidID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Segment is a final transcript segment. Times are in seconds from the start
+// of the session.
+type Segment struct {
+	ID        int     `json:"id"`
+	Speaker   string  `json:"speaker,omitempty"`
+	Text      string  `json:"text"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// Translation is a final translated sentence
+type Translation struct {
+	Language  string  `json:"language"`
+	Speaker   string  `json:"speaker,omitempty"`
+	Text      string  `json:"text"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// Session is a stored transcription session
+type Session struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Tags        []string          `json:"tags"`
+	Source      string            `json:"source"`
+	Language    string            `json:"language,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+	EndedAt     *time.Time        `json:"ended_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	RecordingID string            `json:"recording_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Speakers maps diarization labels such as "S1" to names. Segments keep
+	// their labels; names are applied when the transcript is presented.
+	Speakers map[string]string `json:"speakers,omitempty"`
+	// SpeakerIdentifiers holds the engine's voice identifiers per speaker
+	// label, used to enroll the speaker for identification in later sessions
+	SpeakerIdentifiers map[string][]string `json:"speaker_identifiers,omitempty"`
+
+	Segments     []Segment     `json:"segments"`
+	Translations []Translation `json:"translations"`
+	// Revisions records edits to Segments, oldest first
+	Revisions []Revision `json:"revisions,omitempty"`
+	// MeetingSummary is set once the session has been summarized
+	MeetingSummary *MeetingSummary `json:"summary,omitempty"`
+}
+
+// Summary is the list view of a session
+type Summary struct {
+	ID           string            `json:"id"`
+	Title        string            `json:"title"`
+	Tags         []string          `json:"tags"`
+	Source       string            `json:"source"`
+	Language     string            `json:"language,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	EndedAt      *time.Time        `json:"ended_at,omitempty"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	Segments     int               `json:"segments"`
+	Duration     float64           `json:"duration_seconds"`
+	Translations []string          `json:"translation_languages,omitempty"`
+	Speakers     map[string]string `json:"speakers,omitempty"`
+	Revisions    int               `json:"revisions"`
+	Summarized   bool              `json:"summarized"`
+}
+
+// Summary returns the list view of the session
+func (s *Session) Summary() Summary {
+	sum := Summary{
+		ID:         s.ID,
+		Title:      s.Title,
+		Tags:       s.Tags,
+		Source:     s.Source,
+		Language:   s.Language,
+		StartedAt:  s.StartedAt,
+		EndedAt:    s.EndedAt,
+		UpdatedAt:  s.UpdatedAt,
+		Segments:   len(s.Segments),
+		Speakers:   s.Speakers,
+		Revisions:  len(s.Revisions),
+		Summarized: s.MeetingSummary != nil,
+	}
+	if n := len(s.Segments); n > 0 {
+		sum.Duration = s.Segments[n-1].EndTime
+	}
+	seen := make(map[string]bool)
+	for _, t := range s.Translations {
+		if !seen[t.Language] {
+			seen[t.Language] = true
+			sum.Translations = append(sum.Translations, t.Language)
+		}
+	}
+	return sum
+}
+
+// HasTag reports whether the session carries the tag
+func (s *Session) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize fills in defaults so that stored sessions are always complete
+func (s *Session) normalize() {
+	if s.Tags == nil {
+		s.Tags = []string{}
+	}
+	if s.Segments == nil {
+		s.Segments = []Segment{}
+	}
+	if s.Translations == nil {
+		s.Translations = []Translation{}
+	}
+	for i := range s.Segments {
+		if s.Segments[i].ID == 0 {
+			s.Segments[i].ID = s.nextSegmentID()
+		}
+	}
+}
+
+// PrepareImport readies a session uploaded by a client to be stored. The
+// fields the server maintains, such as the revision history, the summary and
+// the recording, are cleared and the segments are numbered from 1, so that
+// edits and reverts can rely on unique segment IDs.
+func (s *Session) PrepareImport() {
+	s.Source = SourceImport
+	s.Revisions = nil
+	s.MeetingSummary = nil
+	s.RecordingID = ""
+	s.UpdatedAt = time.Time{}
+	for i := range s.Segments {
+		s.Segments[i].ID = i + 1
+	}
+}
+
+// nextSegmentID returns an ID not used by any current segment or by a
+// segment that only exists in the revision history
+func (s *Session) nextSegmentID() int {
+	maxID := 0
+	for _, seg := range s.Segments {
+		maxID = max(maxID, seg.ID)
+	}
+	for _, r := range s.Revisions {
+		for _, seg := range r.After {
+			maxID = max(maxID, seg.ID)
+		}
+		for _, seg := range r.Before {
+			maxID = max(maxID, seg.ID)
+		}
+	}
+	return maxID + 1
+}
+
+// Store keeps sessions as JSON files in a directory. The web server and the
+// provider may share the directory: each write replaces the file atomically,
+// and read-modify-write cycles hold a file lock on "<id>.lock" so that
+// concurrent updates from both processes are not lost. On platforms without
+// flock only one process may write.
+type Store struct {
+	dir    string
+	logger *slog.Logger
+
+	// mu serializes read-modify-write cycles within this process
+	mu sync.Mutex
+}
+
+// NewStore creates the sessions directory if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	return &Store{dir: dir, logger: logging.For("sessions")}, nil
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
+
+func (st *Store) lockPath(id string) string {
+	return filepath.Join(st.dir, id+".lock")
+}
+
+// Create stores a new session. A missing ID is generated.
+func (st *Store) Create(s *Session) error {
+	if s.ID == "" {
+		s.ID = logging.NewID()
+	}
+	if !ValidID.MatchString(s.ID) {
+		return fmt.Errorf("invalid session ID %q", s.ID)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	unlock, err := st.lock(s.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(st.path(s.ID)); err == nil {
+		return ErrExists
+	}
+	if s.StartedAt.IsZero() {
+		s.StartedAt = time.Now().UTC()
+	}
+	if s.Title == "" {
+		s.Title = "Session " + s.StartedAt.Format("2006-01-02 15:04")
+	}
+	return st.write(s)
+}
+
+// Get returns a session
+func (st *Store) Get(id string) (*Session, error) {
+	if !ValidID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	s.normalize()
+	return &s, nil
+}
+
+// List returns the summaries of all sessions, most recent first. When tag is
+// not empty only sessions with that tag are returned.
+func (st *Store) List(tag string) ([]Summary, error) {
+	all, err := st.All()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]Summary, 0, len(all))
+	for _, s := range all {
+		if tag != "" && !s.HasTag(tag) {
+			continue
+		}
+		summaries = append(summaries, s.Summary())
+	}
+	return summaries, nil
+}
+
+// All loads every stored session, most recent first
+func (st *Store) All() ([]*Session, error) {
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	all := make([]*Session, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !ValidID.MatchString(id) {
+			continue
+		}
+		s, err := st.Get(id)
+		if err != nil {
+			st.logger.Warn("Skipping unreadable session", "id", id, "error", err)
+			continue
+		}
+		all = append(all, s)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].StartedAt.After(all[j].StartedAt)
+	})
+	return all, nil
+}
+
+// Modified returns the modification time of every stored session. It is
+// cheaper than All and lets callers such as the search index find changes.
+func (st *Store) Modified() (map[string]time.Time, error) {
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+	modified := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !ValidID.MatchString(id) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// Deleted since ReadDir
+			continue
+		}
+		modified[id] = info.ModTime()
+	}
+	return modified, nil
+}
+
+// Update applies fn to the stored session and writes the result. The session
+// is not written when fn returns an error.
+func (st *Store) Update(id string, fn func(*Session) error) (*Session, error) {
+	if !ValidID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	unlock, err := st.lock(id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := st.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(s); err != nil {
+		return nil, err
+	}
+	if err := st.write(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Delete removes a session
+func (st *Store) Delete(id string) error {
+	if !ValidID.MatchString(id) {
+		return ErrNotFound
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	unlock, err := st.lock(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = os.Remove(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	// Writers still waiting on the lock find the session gone
+	os.Remove(st.lockPath(id))
+	return nil
+}
+
+// write stores the session atomically. The caller holds st.mu and the
+// session's lock.
+func (st *Store) write(s *Session) error {
+	s.normalize()
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := st.path(s.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, st.path(s.ID)); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentUpdatesFromTwoStores checks that two stores sharing a
+// directory, like the web server and the provider, do not lose each other's
+// updates
+func TestConcurrentUpdatesFromTwoStores(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("only one process may write without flock")
+	}
+	dir := t.TempDir()
+	a, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Create(&Session{ID: "shared"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const updates = 50
+	var wg sync.WaitGroup
+	for i, st := range []*Store{a, b} {
+		for j := 0; j < updates; j++ {
+			wg.Add(1)
+			go func(tag string) {
+				defer wg.Done()
+				if _, err := st.Update("shared", func(s *Session) error {
+					s.Tags = append(s.Tags, tag)
+					return nil
+				}); err != nil {
+					t.Error(err)
+				}
+			}(fmt.Sprintf("%d-%d", i, j))
+		}
+	}
+	wg.Wait()
+
+	s, err := a.Get("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tags) != 2*updates {
+		t.Errorf("stored %d tags, want %d", len(s.Tags), 2*updates)
+	}
+}
+
+func TestWriterStopsWhenSessionDeleted(t *testing.T) {
+	st, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := st.Start(context.Background(), &Session{ID: "deleted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete("deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Force the next segment to flush
+	w.mu.Lock()
+	w.lastFlush = time.Time{}
+	w.mu.Unlock()
+	w.AddSegment(Segment{Text: "hello"})
+	w.AddSegment(Segment{Text: "again"})
+
+	w.mu.Lock()
+	deleted, buffered := w.deleted, len(w.segments)
+	w.mu.Unlock()
+	if !deleted || buffered != 0 {
+		t.Errorf("writer deleted = %v with %d buffered segments, want stopped and empty", deleted, buffered)
+	}
+	if err := w.Close(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Close error = %v, want ErrNotFound", err)
+	}
+	if _, err := st.Get("deleted"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted session was stored again: %v", err)
+	}
+}
+
+func TestWriterIgnoresSegmentsAfterClose(t *testing.T) {
+	st, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := st.Start(context.Background(), &Session{ID: "closed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.AddSegment(Segment{Text: "hello"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	ended, err := st.Get("closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A late final from the hub or the stream teardown
+	w.mu.Lock()
+	w.lastFlush = time.Time{}
+	w.mu.Unlock()
+	w.AddSegment(Segment{Text: "late"})
+	w.AddTranslation(Translation{Language: "ja", Text: "遅い"})
+
+	s, err := st.Get("closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Segments) != 1 || len(s.Translations) != 0 || !s.UpdatedAt.Equal(ended.UpdatedAt) {
+		t.Errorf("ended session changed: %d segments, %d translations", len(s.Segments), len(s.Translations))
+	}
+	w.mu.Lock()
+	buffered := len(w.segments) + len(w.translations)
+	w.mu.Unlock()
+	if buffered != 0 {
+		t.Errorf("closed writer buffered %d items", buffered)
+	}
+}
+
+func TestPrepareImport(t *testing.T) {
+	s := &Session{
+		Source:      SourceRealtime,
+		RecordingID: "rec",
+		Segments: []Segment{
+			{ID: 7, Text: "one"},
+			{ID: 7, Text: "two"},
+			{Text: "three"},
+		},
+		Revisions:      []Revision{{ID: 1, Operation: OpEdit, After: []Segment{{ID: 99}}}},
+		MeetingSummary: &MeetingSummary{Summary: "forged"},
+	}
+	s.PrepareImport()
+
+	if s.Source != SourceImport || s.RecordingID != "" || s.Revisions != nil || s.MeetingSummary != nil {
+		t.Errorf("server fields kept: %+v", s)
+	}
+	for i, seg := range s.Segments {
+		if seg.ID != i+1 {
+			t.Errorf("segment %d has ID %d, want %d", i, seg.ID, i+1)
+		}
+	}
+
+	// Edits address one segment, not every segment sharing its old ID
+	if _, err := s.EditSegment("tester", 2, ptr("TWO"), nil); err != nil {
+		t.Fatalf("EditSegment error: %v", err)
+	}
+	if s.Segments[0].Text != "one" || s.Segments[1].Text != "TWO" {
+		t.Errorf("segments after edit = %+v", s.Segments)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,58 @@
+package sessions
+
+import (
+	"maps"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSpeakerName bounds speaker names, which end up in subtitles and events
+const maxSpeakerName = 100
+
+// SpeakerName returns the name assigned to a speaker label, or the label
+// itself when the speaker has not been named
+func (s *Session) SpeakerName(label string) string {
+	if name, ok := s.Speakers[label]; ok {
+		return name
+	}
+	return label
+}
+
+// RenameSpeakers assigns names to speaker labels. Names are merged into the
+// existing mapping; an empty name removes the name of that label.
+func (s *Session) RenameSpeakers(names map[string]string) error {
+	merged, err := MergeSpeakers(s.Speakers, names)
+	if err != nil {
+		return err
+	}
+	s.Speakers = merged
+	return nil
+}
+
+// MergeSpeakers returns current with names applied, leaving current
+// unchanged. Labels and names are trimmed, an empty name removes the label
+// and the result is nil when no speaker is named.
+func MergeSpeakers(current, names map[string]string) (map[string]string, error) {
+	merged := maps.Clone(current)
+	for label, name := range names {
+		label, name = strings.TrimSpace(label), strings.TrimSpace(name)
+		if label == "" {
+			return nil, invalidEdit("speaker label must not be empty")
+		}
+		if utf8.RuneCountInString(name) > maxSpeakerName {
+			return nil, invalidEdit("name of speaker %s is longer than %d characters", label, maxSpeakerName)
+		}
+		if name == "" {
+			delete(merged, label)
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]string)
+		}
+		merged[label] = name
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
@@ -0,0 +1,193 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// flushInterval bounds how long new segments are buffered before they are
+// written, so that other machines see a live session update while it runs
+const flushInterval = 5 * time.Second
+
+// Writer appends the results of a live session to the store. Segments are
+// buffered and written in batches; edits made through the API in the
+// meantime (title, tags) are preserved because every flush re-reads the
+// stored session. A nil *Writer is valid and stores nothing.
+type Writer struct {
+	store  *Store
+	id     string
+	logger *slog.Logger
+
+	mu           sync.Mutex
+	segments     []Segment
+	translations []Translation
+	speakers     map[string]string
+	lastFlush    time.Time
+	closed       bool
+	// deleted is set when the session was deleted while it ran; nothing
+	// more is stored
+	deleted bool
+}
+
+// Start creates a live session and returns a writer for its results
+func (st *Store) Start(ctx context.Context, s *Session) (*Writer, error) {
+	if err := st.Create(s); err != nil {
+		return nil, err
+	}
+	st.logger.InfoContext(ctx, "Storing session", "stored_session_id", s.ID)
+	return &Writer{store: st, id: s.ID, logger: st.logger, speakers: maps.Clone(s.Speakers), lastFlush: time.Now()}, nil
+}
+
+// ID returns the stored session ID
+func (w *Writer) ID() string {
+	if w == nil {
+		return ""
+	}
+	return w.id
+}
+
+// AddSegment appends a final transcript segment. Segments added after Close
+// are dropped, so that an ended session is not changed.
+func (w *Writer) AddSegment(seg Segment) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deleted || w.closed {
+		return
+	}
+	w.segments = append(w.segments, seg)
+	w.maybeFlush()
+}
+
+// AddTranslation appends a final translation. Like segments, translations
+// added after Close are dropped.
+func (w *Writer) AddTranslation(t Translation) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deleted || w.closed {
+		return
+	}
+	w.translations = append(w.translations, t)
+	w.maybeFlush()
+}
+
+// Update changes session fields that are only known once the session runs,
+// such as the language or the recording ID
+func (w *Writer) Update(fn func(*Session)) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s, err := w.store.Update(w.id, func(s *Session) error {
+		fn(s)
+		return nil
+	})
+	if err != nil {
+		w.logger.Warn("Failed to update session", "stored_session_id", w.id, "error", err)
+		return
+	}
+	w.speakers = maps.Clone(s.Speakers)
+}
+
+// RenameSpeakers names speaker labels while the session runs. The names take
+// effect immediately for Speakers and are stored with the session.
+func (w *Writer) RenameSpeakers(names map[string]string) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	merged, err := MergeSpeakers(w.speakers, names)
+	if err != nil {
+		return err
+	}
+	w.speakers = merged
+	_, err = w.store.Update(w.id, func(s *Session) error {
+		return s.RenameSpeakers(names)
+	})
+	return err
+}
+
+// Speakers returns the speaker names of the session. Names set through the
+// sessions API while the session runs are picked up on the next flush. The
+// second result is false for a nil Writer.
+func (w *Writer) Speakers() (map[string]string, bool) {
+	if w == nil {
+		return nil, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return maps.Clone(w.speakers), true
+}
+
+// maybeFlush writes buffered results when the flush interval has passed. The
+// caller holds w.mu.
+func (w *Writer) maybeFlush() {
+	if time.Since(w.lastFlush) < flushInterval {
+		return
+	}
+	err := w.flush(nil)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		w.logger.Info("Session deleted while running, no longer storing it", "stored_session_id", w.id)
+	case err != nil:
+		w.logger.Warn("Failed to store session results, will retry", "stored_session_id", w.id, "error", err)
+	}
+}
+
+// flush writes buffered results. The caller holds w.mu.
+func (w *Writer) flush(fn func(*Session)) error {
+	s, err := w.store.Update(w.id, func(s *Session) error {
+		for _, seg := range w.segments {
+			seg.ID = s.nextSegmentID()
+			s.Segments = append(s.Segments, seg)
+		}
+		s.Translations = append(s.Translations, w.translations...)
+		if fn != nil {
+			fn(s)
+		}
+		return nil
+	})
+	w.lastFlush = time.Now()
+	if errors.Is(err, ErrNotFound) {
+		w.deleted = true
+		w.segments, w.translations = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	w.segments, w.translations = nil, nil
+	w.speakers = maps.Clone(s.Speakers)
+	return nil
+}
+
+// Close writes the remaining results and marks the session as ended
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.deleted {
+		return ErrNotFound
+	}
+
+	return w.flush(func(s *Session) {
+		ended := time.Now().UTC()
+		s.EndedAt = &ended
+	})
+}
@@ -0,0 +1,214 @@
+package speakers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/speechmatics"
+)
+
+const (
+	// maxName bounds profile names, which become speaker labels
+	maxName = 100
+	// maxIdentifiers keeps the most recent identifiers of a profile
+	maxIdentifiers = 10
+)
+
+var (
+	// ErrNotFound is returned when a profile does not exist
+	ErrNotFound = errors.New("speaker profile not found")
+	// ErrInvalid is returned for names or identifiers that cannot be enrolled
+	ErrInvalid = errors.New("invalid speaker profile")
+)
+
+// reservedLabel matches the labels the engine assigns to unknown speakers.
+// Profile names become labels, so they must not look like these.
+var reservedLabel = regexp.MustCompile(`^(?i:S\d+|UU)$`)
+
+// Profile is an enrolled speaker. Identifiers are opaque voice identifiers
+// returned by the engine for earlier sessions with this speaker.
+type Profile struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Identifiers []string  `json:"identifiers"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Registry keeps the enrolled profiles in a single JSON file
+type Registry struct {
+	file   string
+	logger *slog.Logger
+
+	// mu serializes read-modify-write cycles within this process
+	mu sync.Mutex
+}
+
+// NewRegistry creates the directory of the registry file if needed
+func NewRegistry(file string) (*Registry, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create speakers directory: %w", err)
+	}
+	return &Registry{file: file, logger: logging.For("speakers")}, nil
+}
+
+// List returns all profiles sorted by name
+func (r *Registry) List() ([]Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+// Enroll adds identifiers to the profile with the given name, creating the
+// profile if there is none. It reports whether a profile was created.
+func (r *Registry) Enroll(name string, identifiers []string) (*Profile, bool, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return nil, false, fmt.Errorf("%w: name must not be empty", ErrInvalid)
+	case utf8.RuneCountInString(name) > maxName:
+		return nil, false, fmt.Errorf("%w: name is longer than %d characters", ErrInvalid, maxName)
+	case reservedLabel.MatchString(name):
+		return nil, false, fmt.Errorf("%w: %q is reserved for unidentified speakers", ErrInvalid, name)
+	}
+	var ids []string
+	for _, id := range identifiers {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, false, fmt.Errorf("%w: no speaker identifiers", ErrInvalid)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profiles, err := r.load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC()
+	i := indexOf(profiles, name)
+	created := i < 0
+	if created {
+		profiles = append(profiles, Profile{ID: logging.NewID(), Name: name, CreatedAt: now})
+		i = len(profiles) - 1
+	}
+	p := &profiles[i]
+	p.Identifiers = mergeIdentifiers(p.Identifiers, ids)
+	p.UpdatedAt = now
+
+	if err := r.save(profiles); err != nil {
+		return nil, false, err
+	}
+	enrolled := *p
+	r.logger.Info("Speaker enrolled", "profile_id", enrolled.ID, "created", created, "identifiers", len(enrolled.Identifiers))
+	return &enrolled, created, nil
+}
+
+// Delete removes a profile
+func (r *Registry) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profiles, err := r.load()
+	if err != nil {
+		return err
+	}
+	for i, p := range profiles {
+		if p.ID == id {
+			return r.save(append(profiles[:i], profiles[i+1:]...))
+		}
+	}
+	return ErrNotFound
+}
+
+// Known returns the profiles in the form the engine expects, labelled by name
+func (r *Registry) Known() ([]speechmatics.SpeakerIdentifiers, error) {
+	profiles, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	known := make([]speechmatics.SpeakerIdentifiers, 0, len(profiles))
+	for _, p := range profiles {
+		known = append(known, speechmatics.SpeakerIdentifiers{Label: p.Name, Identifiers: p.Identifiers})
+	}
+	return known, nil
+}
+
+// load reads the registry file. A missing file is an empty registry. The
+// caller holds r.mu.
+func (r *Registry) load() ([]Profile, error) {
+	data, err := os.ReadFile(r.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Profile{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read speaker profiles: %w", err)
+	}
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse speaker profiles: %w", err)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
+	return profiles, nil
+}
+
+// save writes the registry file atomically. The caller holds r.mu.
+func (r *Registry) save(profiles []Profile) error {
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write speaker profiles: %w", err)
+	}
+	if err := os.Rename(tmp, r.file); err != nil {
+		return fmt.Errorf("failed to write speaker profiles: %w", err)
+	}
+	return nil
+}
+
+// indexOf returns the index of the profile with the name, ignoring case,
+// or -1
+func indexOf(profiles []Profile, name string) int {
+	for i, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeIdentifiers appends new identifiers and keeps the most recent
+// maxIdentifiers
+func mergeIdentifiers(current, add []string) []string {
+	seen := make(map[string]bool, len(current))
+	for _, id := range current {
+		seen[id] = true
+	}
+	merged := append([]string(nil), current...)
+	for _, id := range add {
+		if !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	if len(merged) > maxIdentifiers {
+		merged = merged[len(merged)-maxIdentifiers:]
+	}
+	return merged
+}
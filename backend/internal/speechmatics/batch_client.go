@@ -2,33 +2,137 @@ package speechmatics
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
-)
 
-const (
-	batchAPIBaseURL = "https://asr.api.speechmatics.com/v2"
-	defaultTimeout  = 30 * time.Second
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BatchClient handles interactions with Speechmatics Batch API
 type BatchClient struct {
+	cfg        *config.Manager
+	logger     *slog.Logger
+	tracer     trace.Tracer
 	apiKey     string
 	httpClient *http.Client
+	regions    *regionPool
+
+	// jobs remembers which region accepted each job, since job IDs are only
+	// valid in the region they were submitted to. Entries expire after
+	// jobIdleTTL without use; expired jobs are located again by asking each
+	// region.
+	jobs sync.Map
+}
+
+// jobIdleTTL is how long a job's region is remembered after its last use
+const jobIdleTTL = time.Hour
+
+// jobInfo tracks a submitted job for routing and metrics
+type jobInfo struct {
+	region    Region
+	submitted time.Time
+	language  string
+	engine    string
+	// lastUsed is the Unix time in nanoseconds of the last lookup
+	lastUsed atomic.Int64
+
+	mu       sync.Mutex
+	finished bool
 }
 
-// NewBatchClient creates a new Speechmatics Batch API client
-func NewBatchClient(apiKey string) *BatchClient {
+// IsFinalStatus reports whether a job status is final, i.e. the job will
+// not change status again
+func IsFinalStatus(status string) bool {
+	switch status {
+	case "done", "rejected", "deleted", "expired", "error":
+		return true
+	}
+	return false
+}
+
+// observe records metrics the first time a job reaches a final status
+func (j *jobInfo) observe(status string) {
+	if !IsFinalStatus(status) {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.finished {
+		return
+	}
+	j.finished = true
+
+	metrics.BatchJobs.WithLabelValues(status, j.language, j.engine).Inc()
+	if !j.submitted.IsZero() {
+		metrics.BatchJobDuration.WithLabelValues(status, j.language, j.engine).Observe(metrics.Since(j.submitted))
+	}
+}
+
+// job returns the tracked job jobID and marks it as used
+func (c *BatchClient) job(jobID string) (*jobInfo, bool) {
+	v, ok := c.jobs.Load(jobID)
+	if !ok {
+		return nil, false
+	}
+	job := v.(*jobInfo)
+	job.lastUsed.Store(time.Now().UnixNano())
+	return job, true
+}
+
+// trackJob remembers a job and forgets the jobs that have not been used
+// for jobIdleTTL
+func (c *BatchClient) trackJob(jobID string, job *jobInfo) {
+	now := time.Now()
+	job.lastUsed.Store(now.UnixNano())
+	c.jobs.Store(jobID, job)
+	cutoff := now.Add(-jobIdleTTL).UnixNano()
+	c.jobs.Range(func(id, v interface{}) bool {
+		if v.(*jobInfo).lastUsed.Load() < cutoff {
+			c.jobs.Delete(id)
+		}
+		return true
+	})
+}
+
+// NewBatchClient creates a new Speechmatics Batch API client for the configured regions
+func NewBatchClient(cfg *config.Manager) (*BatchClient, error) {
+	sm := cfg.Current().Speechmatics
+	if sm.APIKey == "" {
+		return nil, fmt.Errorf("speechmatics API key not configured")
+	}
+
+	regions, err := LookupRegions(sm.Regions)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logging.For("speechmatics")
 	return &BatchClient{
-		apiKey: apiKey,
+		cfg:    cfg,
+		logger: logger,
+		tracer: tracing.Tracer("speechmatics"),
+		apiKey: sm.APIKey,
 		httpClient: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout:   sm.RequestTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
-	}
+		regions: newRegionPool(regions, func(r Region) string { return r.BatchURL }, logger),
+	}, nil
 }
 
 // TranscriptionConfig represents the transcription configuration
@@ -38,12 +142,19 @@ type TranscriptionConfig struct {
 	EnablePartials bool    `json:"enable_partials,omitempty"`
 	OperatingPoint string  `json:"operating_point,omitempty"`
 	MaxDelay       float64 `json:"max_delay,omitempty"`
+	// SpeakerDiarizationConfig identifies enrolled speakers; it requires
+	// speaker diarization
+	SpeakerDiarizationConfig *SpeakerDiarizationConfig `json:"speaker_diarization_config,omitempty"`
 }
 
 // JobConfig represents the job configuration
 type JobConfig struct {
 	Type                string              `json:"type"`
 	TranscriptionConfig TranscriptionConfig `json:"transcription_config"`
+	// TranslationConfig translates the transcript as part of the job
+	TranslationConfig *TranslationConfig `json:"translation_config,omitempty"`
+	// SummarizationConfig summarizes the transcript as part of the job
+	SummarizationConfig *SummarizationConfig `json:"summarization_config,omitempty"`
 }
 
 // JobResponse represents the response from job submission
@@ -60,8 +171,24 @@ type TranscriptResponse struct {
 		CreatedAt string  `json:"created_at"`
 		Duration  float64 `json:"duration"`
 		Language  string  `json:"language"`
+		// TranscriptionConfig echoes the job configuration in json-v2 output
+		TranscriptionConfig struct {
+			Language string `json:"language"`
+		} `json:"transcription_config"`
 	} `json:"metadata"`
+	Job struct {
+		ID        string `json:"id"`
+		DataName  string `json:"data_name"`
+		CreatedAt string `json:"created_at"`
+	} `json:"job"`
 	Results []TranscriptResult `json:"results"`
+	// Speakers holds speaker identifiers when the job set get_speakers
+	Speakers []SpeakerIdentifiers `json:"speakers,omitempty"`
+	// Translations holds the translated sentences by language when the job
+	// set a translation config
+	Translations map[string][]TranslatedSentence `json:"translations,omitempty"`
+	// Summary is set when the job set a summarization config
+	Summary *Summary `json:"summary,omitempty"`
 }
 
 // TranscriptResult represents a single transcript segment
@@ -77,7 +204,13 @@ type TranscriptResult struct {
 }
 
 // SubmitJob submits an audio file for transcription
-func (c *BatchClient) SubmitJob(audioData []byte, filename string, config *JobConfig) (*JobResponse, error) {
+func (c *BatchClient) SubmitJob(ctx context.Context, audioData []byte, filename string, config *JobConfig) (_ *JobResponse, err error) {
+	ctx, span := c.tracer.Start(ctx, "speechmatics.SubmitJob", trace.WithAttributes(
+		attribute.Int("audio.bytes", len(audioData)),
+		attribute.String("transcription.language", config.TranscriptionConfig.Language),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	// Create multipart form
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -104,19 +237,54 @@ func (c *BatchClient) SubmitJob(audioData []byte, filename string, config *JobCo
 		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
+	// Try each region in turn until one accepts the job
+	var lastErr error
+	for _, region := range c.regions.Ordered() {
+		jobResp, err := c.submitJobToRegion(ctx, region, body.Bytes(), writer.FormDataContentType())
+		if err == nil {
+			span.SetAttributes(tracing.Region(region.Name), attribute.String("job.id", jobResp.ID))
+			c.regions.MarkHealthy(region.Name)
+			job := &jobInfo{
+				region:    region,
+				submitted: time.Now(),
+				language:  metrics.Language(config.TranscriptionConfig.Language),
+				engine:    metrics.Engine(config.TranscriptionConfig.OperatingPoint),
+			}
+			c.trackJob(jobResp.ID, job)
+			c.logger.InfoContext(ctx, "Batch job submitted", "job_id", jobResp.ID, "region", region.Name)
+			metrics.BatchJobs.WithLabelValues("submitted", job.language, job.engine).Inc()
+			return jobResp, nil
+		}
+		if !isRegionError(err) {
+			return nil, err
+		}
+
+		c.regions.MarkFailed(region.Name, err)
+		lastErr = err
+		c.logger.WarnContext(ctx, "Failing over batch job submission", "region", region.Name, "error", err)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no Speechmatics regions available")
+	}
+	return nil, fmt.Errorf("all Speechmatics regions failed: %w", lastErr)
+}
+
+// submitJobToRegion sends a prepared multipart job submission to a single region
+func (c *BatchClient) submitJobToRegion(ctx context.Context, region Region, body []byte, contentType string) (*JobResponse, error) {
 	// Create request
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/jobs/", batchAPIBaseURL), body)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/jobs/", region.BatchURL), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
 
 	// Send request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, &RegionError{Region: region.Name, Err: fmt.Errorf("failed to send request: %w", err)}
 	}
 	defer resp.Body.Close()
 
@@ -127,7 +295,11 @@ func (c *BatchClient) SubmitJob(audioData []byte, filename string, config *JobCo
 	}
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		apiErr := fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		if isRegionStatus(resp.StatusCode) {
+			return nil, &RegionError{Region: region.Name, Err: apiErr}
+		}
+		return nil, apiErr
 	}
 
 	// Parse response
@@ -139,9 +311,47 @@ func (c *BatchClient) SubmitJob(audioData []byte, filename string, config *JobCo
 	return &jobResp, nil
 }
 
-// GetJobStatus retrieves the status of a transcription job
-func (c *BatchClient) GetJobStatus(jobID string) (*JobResponse, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/jobs/%s", batchAPIBaseURL, jobID), http.NoBody)
+// isRegionStatus reports whether an HTTP status indicates a quota or
+// availability problem that another region may not have
+func isRegionStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// doJobRequest sends a GET request for an existing job to the region that owns it.
+// Jobs submitted before a restart are located by asking each region in turn.
+// Requests for a known job never fail over: the job only exists in the region
+// that accepted it, so an error from that region is returned and the caller
+// polls again.
+func (c *BatchClient) doJobRequest(ctx context.Context, jobID, path string) (*http.Response, error) {
+	if job, ok := c.job(jobID); ok {
+		return c.getFromRegion(ctx, job.region, path)
+	}
+
+	var lastErr error
+	for _, region := range c.regions.Ordered() {
+		resp, err := c.getFromRegion(ctx, region, path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("job %s not found in region %s", jobID, region.Name)
+			continue
+		}
+		c.trackJob(jobID, &jobInfo{region: region})
+		return resp, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no Speechmatics regions available")
+	}
+	return nil, lastErr
+}
+
+// getFromRegion sends an authorized GET request to a region's batch API
+func (c *BatchClient) getFromRegion(ctx context.Context, region Region, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", region.BatchURL+path, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -152,6 +362,18 @@ func (c *BatchClient) GetJobStatus(jobID string) (*JobResponse, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	return resp, nil
+}
+
+// GetJobStatus retrieves the status of a transcription job
+func (c *BatchClient) GetJobStatus(ctx context.Context, jobID string) (_ *JobResponse, err error) {
+	ctx, span := c.tracer.Start(ctx, "speechmatics.GetJobStatus", trace.WithAttributes(attribute.String("job.id", jobID)))
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.doJobRequest(ctx, jobID, fmt.Sprintf("/jobs/%s", jobID))
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
@@ -164,25 +386,29 @@ func (c *BatchClient) GetJobStatus(jobID string) (*JobResponse, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if job, ok := c.job(jobID); ok {
+		job.observe(jobResp.Status)
+	}
+	span.SetAttributes(attribute.String("job.status", jobResp.Status))
+
 	return &jobResp, nil
 }
 
 // GetTranscript retrieves the transcript for a completed job
-func (c *BatchClient) GetTranscript(jobID, format string) (*TranscriptResponse, error) {
+func (c *BatchClient) GetTranscript(ctx context.Context, jobID, format string) (_ *TranscriptResponse, err error) {
+	ctx, span := c.tracer.Start(ctx, "speechmatics.GetTranscript", trace.WithAttributes(
+		attribute.String("job.id", jobID),
+		attribute.String("transcript.format", format),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	if format == "" {
 		format = "json-v2"
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/jobs/%s/transcript?format=%s", batchAPIBaseURL, jobID, format), http.NoBody)
+	resp, err := c.doJobRequest(ctx, jobID, fmt.Sprintf("/jobs/%s/transcript?format=%s", jobID, format))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -213,28 +439,57 @@ func (c *BatchClient) GetTranscript(jobID, format string) (*TranscriptResponse,
 	return &transcriptResp, nil
 }
 
+// Ping checks that the batch API of the preferred region is reachable and
+// accepts the API key
+func (c *BatchClient) Ping(ctx context.Context) error {
+	regions := c.regions.Ordered()
+	if len(regions) == 0 {
+		return fmt.Errorf("no Speechmatics regions available")
+	}
+
+	resp, err := c.getFromRegion(ctx, regions[0], "/jobs?limit=1")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error (status %d) in region %s", resp.StatusCode, regions[0].Name)
+	}
+	return nil
+}
+
 // WaitForCompletion polls the job status until it's completed or failed
-func (c *BatchClient) WaitForCompletion(jobID string, maxWaitTime time.Duration) error {
+func (c *BatchClient) WaitForCompletion(ctx context.Context, jobID string, maxWaitTime time.Duration) (err error) {
+	ctx, span := c.tracer.Start(ctx, "speechmatics.WaitForCompletion", trace.WithAttributes(attribute.String("job.id", jobID)))
+	defer func() { tracing.End(span, err) }()
+
 	startTime := time.Now()
-	pollInterval := 2 * time.Second
+	pollInterval := c.cfg.Current().Batch.PollInterval
 
-	for {
+	for polls := 1; ; polls++ {
+		span.SetAttributes(attribute.Int("job.polls", polls))
 		if time.Since(startTime) > maxWaitTime {
 			return fmt.Errorf("timeout waiting for job completion")
 		}
 
-		status, err := c.GetJobStatus(jobID)
+		status, err := c.GetJobStatus(ctx, jobID)
 		if err != nil {
 			return fmt.Errorf("failed to get job status: %w", err)
 		}
 
-		switch status.Status {
-		case "done":
+		switch {
+		case status.Status == "done":
 			return nil
-		case "rejected", "deleted", "error":
+		case IsFinalStatus(status.Status):
 			return fmt.Errorf("job failed with status: %s", status.Status)
 		}
 
-		time.Sleep(pollInterval)
+		c.logger.DebugContext(ctx, "Batch job still running", "job_id", jobID, "status", status.Status)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
 	}
 }
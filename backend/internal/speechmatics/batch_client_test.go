@@ -0,0 +1,44 @@
+package speechmatics
+
+import (
+	"testing"
+
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestIsFinalStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{"running", false},
+		{"queued", false},
+		{"", false},
+		{"done", true},
+		{"rejected", true},
+		{"deleted", true},
+		{"expired", true},
+		{"error", true},
+	}
+	for _, tt := range tests {
+		if got := IsFinalStatus(tt.status); got != tt.want {
+			t.Errorf("IsFinalStatus(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestObserveCountsFinalStatusOnce(t *testing.T) {
+	job := &jobInfo{language: "test-observe", engine: "standard"}
+	expired := metrics.BatchJobs.WithLabelValues("expired", job.language, job.engine)
+
+	job.observe("running")
+	job.observe("expired")
+	job.observe("expired")
+	if got := testutil.ToFloat64(expired); got != 1 {
+		t.Errorf("expired jobs = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.BatchJobs.WithLabelValues("running", job.language, job.engine)); got != 0 {
+		t.Errorf("running jobs = %v, want 0", got)
+	}
+}
@@ -4,69 +4,272 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
-	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dreamtrans/backend/internal/auth"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/dreamtrans/backend/internal/tracing"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	realtimeAPIURL = "wss://eu2.rt.speechmatics.com/v2"
 	// Message types from Speechmatics
-	msgRecognitionStarted   = "RecognitionStarted"
-	msgAddTranscript        = "AddTranscript"
-	msgAddPartialTranscript = "AddPartialTranscript"
-	msgEndOfTranscript      = "EndOfTranscript"
-	msgAudioAdded           = "AudioAdded"
-	msgError                = "Error"
-	msgWarning              = "Warning"
-	msgInfo                 = "Info"
+	msgRecognitionStarted    = "RecognitionStarted"
+	msgAddTranscript         = "AddTranscript"
+	msgAddPartialTranscript  = "AddPartialTranscript"
+	msgAddTranslation        = "AddTranslation"
+	msgAddPartialTranslation = "AddPartialTranslation"
+	msgSpeakersResult        = "SpeakersResult"
+	msgEndOfUtterance        = "EndOfUtterance"
+	msgEndOfTranscript       = "EndOfTranscript"
+	msgAudioAdded            = "AudioAdded"
+	msgError                 = "Error"
+	msgWarning               = "Warning"
+	msgInfo                  = "Info"
 )
 
+// failoverErrorTypes lists Speechmatics error types that indicate a quota or
+// availability problem in the current region rather than a problem with the request
+var failoverErrorTypes = map[string]bool{
+	"quota_exceeded": true,
+	"job_error":      true,
+	"unknown_error":  true,
+}
+
 // Client handles real-time streaming transcription with Speechmatics
 type Client struct {
-	apiKey         string
+	cfg            *config.Manager
+	logger         *slog.Logger
+	tracer         trace.Tracer
 	tokenGenerator *auth.TokenGenerator
+	regions        *regionPool
+	active         atomic.Int64
 }
 
 // NewClient creates a new Speechmatics real-time client
-func NewClient() (*Client, error) {
-	apiKey := os.Getenv("SM_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("SM_API_KEY environment variable not set")
+func NewClient(cfg *config.Manager) (*Client, error) {
+	tokenGen, err := auth.NewTokenGenerator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token generator: %w", err)
 	}
 
-	tokenGen, err := auth.NewTokenGenerator()
+	regions, err := LookupRegions(cfg.Current().Speechmatics.Regions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create token generator: %w", err)
+		return nil, err
 	}
 
+	logger := logging.For("speechmatics")
 	return &Client{
-		apiKey:         apiKey,
+		cfg:            cfg,
+		logger:         logger,
+		tracer:         tracing.Tracer("speechmatics"),
 		tokenGenerator: tokenGen,
+		regions:        newRegionPool(regions, func(r Region) string { return r.RealtimeURL }, logger),
 	}, nil
 }
 
+// session holds the state of a single connection to a Speechmatics region
+type session struct {
+	region   Region
+	conn     *websocket.Conn
+	settings config.TranscriptionConfig
+	stats    *sessionStats
+	// offset is the number of seconds of audio sent to earlier regions. A
+	// region that takes over mid-stream times its results from its own
+	// start, so they are shifted by offset.
+	offset float64
+	// sent is the number of audio bytes sent to this region
+	sent int64
+}
+
+// shift moves an event's times from the region's stream onto the client's
+func (s *session) shift(ev TranscriptEvent) TranscriptEvent {
+	ev.StartTime += s.offset
+	ev.EndTime += s.offset
+	return ev
+}
+
+// duration returns the number of seconds of audio sent to this region
+func (s *session) duration() float64 {
+	return float64(s.sent) / float64(s.settings.SampleRate*bytesPerSample)
+}
+
 // StreamingConfig contains configuration for the streaming transcription
 type StreamingConfig struct {
 	Language       string
 	EnablePartials bool
 	MaxDelay       float64
+	// TargetLanguages enables realtime translation into these languages
+	TargetLanguages []string
+	// KnownSpeakers are enrolled speakers to label by name
+	KnownSpeakers []SpeakerIdentifiers
+	// GetSpeakers requests the identifiers of the session's speakers, which
+	// arrive as an event before the end of the transcript
+	GetSpeakers bool
+	// EndOfUtteranceSilence enables end of utterance events after this many
+	// seconds of silence; 0 disables them
+	EndOfUtteranceSilence float64
 }
 
-// StartStreamingTranscription starts a streaming transcription session
-func (c *Client) StartStreamingTranscription(ctx context.Context, config StreamingConfig, audioInput <-chan []byte, textOutput chan<- string) error {
+// TranscriptEvent is a partial or final transcript or translation received
+// from Speechmatics. Times are in seconds from the start of the audio stream.
+type TranscriptEvent struct {
+	Final     bool
+	Text      string
+	Speaker   string
+	StartTime float64
+	EndTime   float64
+	// Language is the target language for translations and empty for the
+	// source transcript
+	Language string
+	// Speakers is set, without text, on the event carrying the speaker
+	// identifiers requested by StreamingConfig.GetSpeakers
+	Speakers []SpeakerIdentifiers
+	// EndOfUtterance is set, without text, when the engine detected the end
+	// of an utterance (see StreamingConfig.EndOfUtteranceSilence)
+	EndOfUtterance bool
+}
+
+// IsTranslation reports whether the event is a translation
+func (e TranscriptEvent) IsTranslation() bool {
+	return e.Language != ""
+}
+
+// translationMessage is the payload of AddTranslation and AddPartialTranslation
+type translationMessage struct {
+	Language string `json:"language"`
+	Results  []struct {
+		Content   string  `json:"content"`
+		Speaker   string  `json:"speaker"`
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+	} `json:"results"`
+}
+
+// events converts the message into one event per translated sentence
+func (m *translationMessage) events(final bool) []TranscriptEvent {
+	events := make([]TranscriptEvent, 0, len(m.Results))
+	for _, r := range m.Results {
+		if r.Content == "" {
+			continue
+		}
+		events = append(events, TranscriptEvent{
+			Final:     final,
+			Text:      r.Content,
+			Speaker:   r.Speaker,
+			StartTime: r.StartTime,
+			EndTime:   r.EndTime,
+			Language:  m.Language,
+		})
+	}
+	return events
+}
+
+// transcriptMessage is the payload of AddTranscript and AddPartialTranscript
+type transcriptMessage struct {
+	Metadata struct {
+		Transcript string  `json:"transcript"`
+		StartTime  float64 `json:"start_time"`
+		EndTime    float64 `json:"end_time"`
+	} `json:"metadata"`
+	Results []struct {
+		Alternatives []struct {
+			Speaker string `json:"speaker"`
+		} `json:"alternatives"`
+	} `json:"results"`
+}
+
+// event converts the message into a transcript event. The speaker is the one
+// attached to the first word that carries a speaker label.
+func (m *transcriptMessage) event(final bool) TranscriptEvent {
+	ev := TranscriptEvent{
+		Final:     final,
+		Text:      m.Metadata.Transcript,
+		StartTime: m.Metadata.StartTime,
+		EndTime:   m.Metadata.EndTime,
+	}
+	for _, r := range m.Results {
+		if len(r.Alternatives) > 0 && r.Alternatives[0].Speaker != "" {
+			ev.Speaker = r.Alternatives[0].Speaker
+			break
+		}
+	}
+	return ev
+}
+
+// StartStreamingTranscription starts a streaming transcription session.
+// Regions are tried in latency order; when a dial fails or Speechmatics reports a
+// quota or availability error, the session continues in the next region. Event
+// times stay relative to the start of audioInput across regions.
+func (c *Client) StartStreamingTranscription(ctx context.Context, config StreamingConfig, audioInput <-chan []byte, events chan<- TranscriptEvent) (err error) {
+	defer close(events)
+
+	settings := c.cfg.Current().Transcription
+	ctx, span := c.tracer.Start(ctx, "speechmatics.RealtimeSession", trace.WithAttributes(
+		attribute.String("transcription.language", config.Language),
+		attribute.String("transcription.engine", settings.OperatingPoint),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	active := metrics.ActiveSessions.WithLabelValues(metrics.Language(config.Language), metrics.Engine(settings.OperatingPoint))
+	active.Inc()
+	defer active.Dec()
+	c.active.Add(1)
+	defer c.active.Add(-1)
+
+	var lastErr error
+	var offset float64
+	for _, region := range c.regions.Ordered() {
+		err := c.runSession(ctx, region, config, &offset, audioInput, events)
+		if err == nil || !isRegionError(err) {
+			return err
+		}
+		span.AddEvent("failover", trace.WithAttributes(tracing.Region(region.Name)))
+
+		c.regions.MarkFailed(region.Name, err)
+		lastErr = err
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.logger.WarnContext(ctx, "Failing over realtime session", "region", region.Name, "error", err)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no Speechmatics regions available")
+	}
+	return fmt.Errorf("all Speechmatics regions failed: %w", lastErr)
+}
+
+// ActiveSessions returns the number of streaming sessions in progress
+func (c *Client) ActiveSessions() int {
+	return int(c.active.Load())
+}
+
+// runSession runs a transcription session against a single region until the
+// audio input ends, the context is canceled or an error occurs. offset holds
+// the seconds of audio sent to earlier regions and is advanced by the audio
+// sent to this one.
+func (c *Client) runSession(ctx context.Context, region Region, config StreamingConfig, offset *float64, audioInput <-chan []byte, events chan<- TranscriptEvent) (err error) {
+	ctx, span := c.tracer.Start(ctx, "speechmatics.RealtimeConnection", trace.WithAttributes(tracing.Region(region.Name)))
+	defer func() { tracing.End(span, err) }()
+
 	// Generate temporary JWT token
-	token, err := c.tokenGenerator.GenerateToken()
+	token, err := c.tokenGenerator.GenerateToken(ctx)
 	if err != nil {
+		metrics.UpstreamFailures.WithLabelValues(region.Name, "token").Inc()
 		return fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Build WebSocket URL with JWT
-	wsURL, err := url.Parse(realtimeAPIURL)
+	wsURL, err := url.Parse(region.RealtimeURL)
 	if err != nil {
 		return fmt.Errorf("failed to parse WebSocket URL: %w", err)
 	}
@@ -75,84 +278,147 @@ func (c *Client) StartStreamingTranscription(ctx context.Context, config Streami
 	wsURL.RawQuery = q.Encode()
 
 	// Connect to WebSocket
-	log.Printf("Connecting to Speechmatics WebSocket at %s", wsURL.String())
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+	c.logger.InfoContext(ctx, "Connecting to Speechmatics WebSocket", "region", region.Name, "url", region.RealtimeURL)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL.String(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		metrics.UpstreamFailures.WithLabelValues(region.Name, "dial").Inc()
+		return &RegionError{Region: region.Name, Err: fmt.Errorf("failed to connect to WebSocket: %w", err)}
 	}
 	defer conn.Close()
 
 	// Send StartRecognition message
+	defaults := c.cfg.Current().Transcription
+	sess := &session{
+		region:   region,
+		conn:     conn,
+		settings: defaults,
+		stats:    newSessionStats(config.Language, defaults),
+		offset:   *offset,
+	}
 	startMsg := map[string]interface{}{
 		"message": "StartRecognition",
 		"audio_format": map[string]interface{}{
 			"type":        "raw",
 			"encoding":    "pcm_f32le",
-			"sample_rate": 48000,
+			"sample_rate": defaults.SampleRate,
 		},
 		"transcription_config": map[string]interface{}{
 			"language":                 config.Language,
 			"enable_partials":          config.EnablePartials,
-			"operating_point":          "enhanced",
-			"enable_entities":          true,
-			"speaker_diarization":      "speaker",
-			"diarization_max_speakers": 10,
+			"operating_point":          defaults.OperatingPoint,
+			"enable_entities":          defaults.EnableEntities,
+			"speaker_diarization":      defaults.Diarization,
+			"diarization_max_speakers": defaults.DiarizationMaxSpeakers,
 		},
 	}
 
-	if config.MaxDelay > 0 {
-		startMsg["transcription_config"].(map[string]interface{})["max_delay"] = config.MaxDelay
+	maxDelay := config.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaults.MaxDelay
+	}
+	if maxDelay > 0 {
+		startMsg["transcription_config"].(map[string]interface{})["max_delay"] = maxDelay
+	}
+	if config.EndOfUtteranceSilence > 0 {
+		startMsg["transcription_config"].(map[string]interface{})["conversation_config"] = map[string]interface{}{
+			"end_of_utterance_silence_trigger": config.EndOfUtteranceSilence,
+		}
+	}
+	if len(config.KnownSpeakers) > 0 {
+		startMsg["transcription_config"].(map[string]interface{})["speaker_diarization_config"] = map[string]interface{}{
+			"speakers": config.KnownSpeakers,
+		}
+	}
+	if len(config.TargetLanguages) > 0 {
+		startMsg["translation_config"] = map[string]interface{}{
+			"target_languages": config.TargetLanguages,
+			"enable_partials":  config.EnablePartials,
+		}
 	}
 
 	if err := conn.WriteJSON(startMsg); err != nil {
-		return fmt.Errorf("failed to send StartRecognition: %w", err)
+		metrics.UpstreamFailures.WithLabelValues(region.Name, "handshake").Inc()
+		return &RegionError{Region: region.Name, Err: fmt.Errorf("failed to send StartRecognition: %w", err)}
+	}
+	if config.GetSpeakers {
+		// final asks for the identifiers once the whole stream has been heard
+		getSpeakers := map[string]interface{}{"message": "GetSpeakers", "final": true}
+		if err := conn.WriteJSON(getSpeakers); err != nil {
+			return &RegionError{Region: region.Name, Err: fmt.Errorf("failed to send GetSpeakers: %w", err)}
+		}
 	}
 
+	sessionCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		// Stop the audio sender before the next region takes over the input channel
+		cancel()
+		conn.Close()
+		wg.Wait()
+		*offset += sess.duration()
+	}()
+
 	// Create error channel for goroutines
 	errChan := make(chan error, 2)
 
 	// Start goroutine to read messages from WebSocket
-	go c.readMessages(ctx, conn, textOutput, errChan)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		errChan <- c.readMessages(sessionCtx, sess, events)
+	}()
 
 	// Start goroutine to send audio data
-	go c.sendAudio(ctx, conn, audioInput, errChan)
+	go func() {
+		defer wg.Done()
+		c.sendAudio(sessionCtx, sess, audioInput, errChan)
+	}()
 
 	// Wait for context cancellation or error
 	select {
 	case <-ctx.Done():
-		log.Println("Streaming transcription context canceled")
+		c.logger.InfoContext(ctx, "Streaming transcription context canceled")
 		// Send EndOfStream message
 		endMsg := map[string]interface{}{
 			"message": "EndOfStream",
 		}
 		if err := conn.WriteJSON(endMsg); err != nil {
-			log.Printf("Failed to send EndOfStream: %v", err)
+			c.logger.WarnContext(ctx, "Failed to send EndOfStream", "error", err)
 		}
 		return ctx.Err()
 	case err := <-errChan:
+		if err == nil {
+			c.regions.MarkHealthy(region.Name)
+		}
 		return err
 	}
 }
 
-// readMessages reads messages from the WebSocket and processes them
-func (c *Client) readMessages(ctx context.Context, conn *websocket.Conn, textOutput chan<- string, errChan chan<- error) {
-	defer close(textOutput)
-
+// readMessages reads messages from the WebSocket and processes them. It returns
+// nil once the end of the transcript has been received.
+func (c *Client) readMessages(ctx context.Context, sess *session, events chan<- TranscriptEvent) error {
+	region, conn := sess.region, sess.conn
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		default:
 			// Read message with timeout
-			if err := conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
-				log.Printf("Failed to set read deadline: %v", err)
+			if err := conn.SetReadDeadline(time.Now().Add(sess.settings.ReadTimeout)); err != nil {
+				c.logger.WarnContext(ctx, "Failed to set read deadline", "error", err)
 			}
 			messageType, message, err := conn.ReadMessage()
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					errChan <- fmt.Errorf("WebSocket read error: %w", err)
+				if ctx.Err() != nil {
+					return nil
 				}
-				return
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					return fmt.Errorf("WebSocket read error: %w", err)
+				}
+				// Unexpected close frames, resets and read timeouts all
+				// mean the region was lost
+				metrics.UpstreamFailures.WithLabelValues(region.Name, "disconnect").Inc()
+				return &RegionError{Region: region.Name, Err: fmt.Errorf("WebSocket read error: %w", err)}
 			}
 
 			// Skip binary messages (server doesn't send binary to client)
@@ -163,7 +429,7 @@ func (c *Client) readMessages(ctx context.Context, conn *websocket.Conn, textOut
 			// Parse text message as JSON
 			var msg map[string]interface{}
 			if err := json.Unmarshal(message, &msg); err != nil {
-				log.Printf("Failed to parse message: %v", err)
+				c.logger.WarnContext(ctx, "Failed to parse message", "error", err)
 				continue
 			}
 
@@ -175,48 +441,88 @@ func (c *Client) readMessages(ctx context.Context, conn *websocket.Conn, textOut
 
 			switch msgType {
 			case msgRecognitionStarted:
-				log.Println("Recognition started")
-
-			case msgAddTranscript:
-				// Extract final transcript
-				if metadata, ok := msg["metadata"].(map[string]interface{}); ok {
-					if transcript, ok := metadata["transcript"].(string); ok && transcript != "" {
-						select {
-						case textOutput <- transcript:
-						case <-ctx.Done():
-							return
-						}
-					}
+				c.logger.InfoContext(ctx, "Recognition started", "region", region.Name)
+				trace.SpanFromContext(ctx).AddEvent("recognition_started")
+
+			case msgAddTranscript, msgAddPartialTranscript:
+				var tm transcriptMessage
+				if err := json.Unmarshal(message, &tm); err != nil {
+					c.logger.WarnContext(ctx, "Failed to parse transcript", "error", err)
+					continue
+				}
+				if tm.Metadata.Transcript == "" {
+					continue
+				}
+				final := msgType == msgAddTranscript
+				sess.stats.transcript(final)
+				select {
+				case events <- sess.shift(tm.event(final)):
+				case <-ctx.Done():
+					return nil
 				}
 
-			case msgAddPartialTranscript:
-				// Extract partial transcript (optional - could be filtered out)
-				if metadata, ok := msg["metadata"].(map[string]interface{}); ok {
-					if transcript, ok := metadata["transcript"].(string); ok && transcript != "" {
-						// Prefix with [PARTIAL] to distinguish from final transcripts
-						select {
-						case textOutput <- "[PARTIAL] " + transcript:
-						case <-ctx.Done():
-							return
-						}
+			case msgAddTranslation, msgAddPartialTranslation:
+				var tm translationMessage
+				if err := json.Unmarshal(message, &tm); err != nil {
+					c.logger.WarnContext(ctx, "Failed to parse translation", "error", err)
+					continue
+				}
+				for _, ev := range tm.events(msgType == msgAddTranslation) {
+					select {
+					case events <- sess.shift(ev):
+					case <-ctx.Done():
+						return nil
 					}
 				}
 
+			case msgSpeakersResult:
+				var sm speakersMessage
+				if err := json.Unmarshal(message, &sm); err != nil {
+					c.logger.WarnContext(ctx, "Failed to parse speakers", "error", err)
+					continue
+				}
+				if len(sm.Speakers) == 0 {
+					continue
+				}
+				select {
+				case events <- TranscriptEvent{Final: true, Speakers: sm.Speakers}:
+				case <-ctx.Done():
+					return nil
+				}
+
+			case msgEndOfUtterance:
+				var um transcriptMessage
+				if err := json.Unmarshal(message, &um); err != nil {
+					c.logger.WarnContext(ctx, "Failed to parse end of utterance", "error", err)
+					continue
+				}
+				select {
+				case events <- sess.shift(TranscriptEvent{Final: true, EndOfUtterance: true, StartTime: um.Metadata.StartTime, EndTime: um.Metadata.EndTime}):
+				case <-ctx.Done():
+					return nil
+				}
+
 			case msgEndOfTranscript:
-				log.Println("End of transcript received")
-				return
+				c.logger.InfoContext(ctx, "End of transcript received")
+				trace.SpanFromContext(ctx).AddEvent("end_of_transcript")
+				return nil
 
 			case msgError:
-				errorMsg := fmt.Sprintf("Speechmatics error: %v", msg)
-				log.Println(errorMsg)
-				errChan <- fmt.Errorf("%s", errorMsg)
-				return
+				errType, _ := msg["type"].(string)
+				reason, _ := msg["reason"].(string)
+				errorMsg := fmt.Errorf("Speechmatics error %s: %s", errType, reason)
+				c.logger.ErrorContext(ctx, "Speechmatics error", "region", region.Name, "type", errType, "reason", reason)
+				metrics.UpstreamFailures.WithLabelValues(region.Name, errType).Inc()
+				if failoverErrorTypes[errType] {
+					return &RegionError{Region: region.Name, Err: errorMsg}
+				}
+				return errorMsg
 
 			case msgWarning:
-				log.Printf("Speechmatics warning: %v", msg)
+				c.logger.WarnContext(ctx, "Speechmatics warning", "type", msg["type"], "reason", msg["reason"])
 
 			case msgInfo:
-				log.Printf("Speechmatics info: %v", msg)
+				c.logger.InfoContext(ctx, "Speechmatics info", "type", msg["type"], "reason", msg["reason"])
 
 			case msgAudioAdded:
 				// Audio successfully added, no action needed
@@ -226,7 +532,8 @@ func (c *Client) readMessages(ctx context.Context, conn *websocket.Conn, textOut
 }
 
 // sendAudio sends audio data to the WebSocket
-func (c *Client) sendAudio(ctx context.Context, conn *websocket.Conn, audioInput <-chan []byte, errChan chan<- error) {
+func (c *Client) sendAudio(ctx context.Context, sess *session, audioInput <-chan []byte, errChan chan<- error) {
+	conn := sess.conn
 	for {
 		select {
 		case <-ctx.Done():
@@ -238,17 +545,20 @@ func (c *Client) sendAudio(ctx context.Context, conn *websocket.Conn, audioInput
 					"message": "EndOfStream",
 				}
 				if err := conn.WriteJSON(endMsg); err != nil {
-					log.Printf("Failed to send EndOfStream: %v", err)
+					c.logger.WarnContext(ctx, "Failed to send EndOfStream", "error", err)
 				}
 				return
 			}
 
 			// Send audio data as binary message directly
-			if err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
-				log.Printf("Failed to set write deadline: %v", err)
+			if err := conn.SetWriteDeadline(time.Now().Add(sess.settings.WriteTimeout)); err != nil {
+				c.logger.WarnContext(ctx, "Failed to set write deadline", "error", err)
 			}
+			// A chunk that fails to send is still part of the client's stream
+			sess.stats.audio(len(audioData))
+			sess.sent += int64(len(audioData))
 			if err := conn.WriteMessage(websocket.BinaryMessage, audioData); err != nil {
-				errChan <- fmt.Errorf("failed to send audio: %w", err)
+				errChan <- &RegionError{Region: sess.region.Name, Err: fmt.Errorf("failed to send audio: %w", err)}
 				return
 			}
 		}
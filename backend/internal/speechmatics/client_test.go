@@ -0,0 +1,104 @@
+package speechmatics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/gorilla/websocket"
+)
+
+// dialTestServer connects a session to a server that sends messages and
+// then reads until the client goes away
+func dialTestServer(t *testing.T, messages ...string) *session {
+	t.Helper()
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, m := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+				return
+			}
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	settings := config.Default().Transcription
+	return &session{
+		region:   Region{Name: "test"},
+		conn:     conn,
+		settings: settings,
+		stats:    newSessionStats("en", settings),
+	}
+}
+
+func TestReadMessagesShiftsTimesAfterFailover(t *testing.T) {
+	sess := dialTestServer(t,
+		`{"message":"AddTranscript","metadata":{"transcript":"hello","start_time":0.5,"end_time":1.25},"results":[{"alternatives":[{"speaker":"S1"}]}]}`,
+		`{"message":"AddTranslation","language":"ja","results":[{"content":"こんにちは","speaker":"S1","start_time":0.5,"end_time":1.25}]}`,
+		`{"message":"EndOfUtterance","metadata":{"start_time":1.25,"end_time":1.25}}`,
+		`{"message":"EndOfTranscript"}`,
+	)
+	sess.offset = 10
+
+	events := make(chan TranscriptEvent, 10)
+	client := &Client{logger: logging.For("speechmatics")}
+	if err := client.readMessages(context.Background(), sess, events); err != nil {
+		t.Fatalf("readMessages error: %v", err)
+	}
+	close(events)
+
+	want := []TranscriptEvent{
+		{Final: true, Text: "hello", Speaker: "S1", StartTime: 10.5, EndTime: 11.25},
+		{Final: true, Text: "こんにちは", Speaker: "S1", StartTime: 10.5, EndTime: 11.25, Language: "ja"},
+		{Final: true, EndOfUtterance: true, StartTime: 11.25, EndTime: 11.25},
+	}
+	var got []TranscriptEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Text != want[i].Text || got[i].StartTime != want[i].StartTime || got[i].EndTime != want[i].EndTime ||
+			got[i].Language != want[i].Language || got[i].EndOfUtterance != want[i].EndOfUtterance {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSendAudioCountsDuration(t *testing.T) {
+	sess := dialTestServer(t)
+	sess.settings.SampleRate = 16000
+
+	audio := make(chan []byte, 3)
+	second := make([]byte, 16000*bytesPerSample)
+	audio <- second
+	audio <- second[:len(second)/2]
+	close(audio)
+
+	client := &Client{logger: logging.For("speechmatics")}
+	client.sendAudio(context.Background(), sess, audio, make(chan error, 1))
+	if got := sess.duration(); got != 1.5 {
+		t.Errorf("duration = %v, want 1.5", got)
+	}
+}
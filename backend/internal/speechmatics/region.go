@@ -0,0 +1,245 @@
+package speechmatics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	probeTimeout     = 3 * time.Second
+	probeInterval    = 5 * time.Minute
+	failoverCooldown = 2 * time.Minute
+)
+
+// Region describes a Speechmatics deployment serving realtime and batch traffic
+type Region struct {
+	Name        string
+	RealtimeURL string
+	BatchURL    string
+}
+
+// knownRegions maps region names to their public endpoints
+var knownRegions = map[string]Region{
+	"eu": {
+		Name:        "eu",
+		RealtimeURL: "wss://eu2.rt.speechmatics.com/v2",
+		BatchURL:    "https://asr.api.speechmatics.com/v2",
+	},
+	"us": {
+		Name:        "us",
+		RealtimeURL: "wss://us2.rt.speechmatics.com/v2",
+		BatchURL:    "https://us1.asr.api.speechmatics.com/v2",
+	},
+}
+
+// LookupRegions resolves region names (e.g. "us", "eu") to their endpoints.
+// The order of the list is the preference order used before latency probes complete.
+func LookupRegions(names []string) ([]Region, error) {
+	var regions []Region
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		region, ok := knownRegions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown Speechmatics region %q", name)
+		}
+		seen[name] = true
+		regions = append(regions, region)
+	}
+
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no Speechmatics regions configured")
+	}
+	return regions, nil
+}
+
+// regionState tracks probe and failure information for a single region
+type regionState struct {
+	region      Region
+	rank        int
+	latency     time.Duration
+	failedUntil time.Time
+}
+
+// regionPool orders regions by measured latency and skips regions that recently failed
+type regionPool struct {
+	mu        sync.Mutex
+	states    []*regionState
+	endpoint  func(Region) string
+	lastProbe time.Time
+	probing   bool
+	logger    *slog.Logger
+}
+
+// newRegionPool creates a pool for the given regions. endpoint selects the URL
+// that is probed and dialed (realtime or batch).
+func newRegionPool(regions []Region, endpoint func(Region) string, logger *slog.Logger) *regionPool {
+	p := &regionPool{endpoint: endpoint, logger: logger}
+	for i, r := range regions {
+		p.states = append(p.states, &regionState{region: r, rank: i})
+	}
+	return p
+}
+
+// Ordered returns the regions in the order they should be tried: healthy regions
+// sorted by latency first, then regions still cooling down after a failure.
+func (p *regionPool) Ordered() []Region {
+	p.maybeProbe()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	states := make([]*regionState, len(p.states))
+	copy(states, p.states)
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := states[i], states[j]
+		aFailed, bFailed := now.Before(a.failedUntil), now.Before(b.failedUntil)
+		if aFailed != bFailed {
+			return !aFailed
+		}
+		if a.latency > 0 && b.latency > 0 && a.latency != b.latency {
+			return a.latency < b.latency
+		}
+		if (a.latency > 0) != (b.latency > 0) {
+			return a.latency > 0
+		}
+		return a.rank < b.rank
+	})
+
+	regions := make([]Region, len(states))
+	for i, s := range states {
+		regions[i] = s.region
+	}
+	return regions
+}
+
+// MarkFailed puts a region into cooldown so that other regions are preferred
+func (p *regionPool) MarkFailed(name string, reason error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, s := range p.states {
+		if s.region.Name == name {
+			s.failedUntil = time.Now().Add(failoverCooldown)
+			p.logger.Warn("Speechmatics region marked unavailable", "region", name, "cooldown", failoverCooldown, "error", reason)
+			return
+		}
+	}
+}
+
+// MarkHealthy clears any cooldown for a region after a successful request
+func (p *regionPool) MarkHealthy(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, s := range p.states {
+		if s.region.Name == name {
+			s.failedUntil = time.Time{}
+			return
+		}
+	}
+}
+
+// maybeProbe starts a background latency probe if the last one is stale
+func (p *regionPool) maybeProbe() {
+	p.mu.Lock()
+	if len(p.states) < 2 || p.probing || time.Since(p.lastProbe) < probeInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.probing = true
+	p.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout*2)
+		defer cancel()
+		p.Probe(ctx)
+	}()
+}
+
+// Probe measures the TCP connect latency of every region
+func (p *regionPool) Probe(ctx context.Context) {
+	p.mu.Lock()
+	states := make([]*regionState, len(p.states))
+	copy(states, p.states)
+	p.mu.Unlock()
+
+	latencies := make([]time.Duration, len(states))
+	var wg sync.WaitGroup
+	for i, s := range states {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			latency, err := probeEndpoint(ctx, endpoint)
+			if err != nil {
+				p.logger.WarnContext(ctx, "Speechmatics region probe failed", "endpoint", endpoint, "error", err)
+				return
+			}
+			latencies[i] = latency
+		}(i, p.endpoint(s.region))
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, s := range states {
+		s.latency = latencies[i]
+	}
+	p.lastProbe = time.Now()
+	p.probing = false
+}
+
+// probeEndpoint returns the time it takes to open a TCP connection to the endpoint host
+func probeEndpoint(ctx context.Context, endpoint string) (time.Duration, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return 0, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	dialer := net.Dialer{Timeout: probeTimeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	conn.Close()
+	return latency, nil
+}
+
+// RegionError reports a failure that is specific to one region and can be
+// retried in the next region
+type RegionError struct {
+	Region string
+	Err    error
+}
+
+func (e *RegionError) Error() string {
+	return fmt.Sprintf("region %s: %v", e.Region, e.Err)
+}
+
+func (e *RegionError) Unwrap() error {
+	return e.Err
+}
+
+// isRegionError reports whether err should trigger a failover
+func isRegionError(err error) bool {
+	var regionErr *RegionError
+	return errors.As(err, &regionErr)
+}
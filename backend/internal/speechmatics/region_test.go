@@ -0,0 +1,19 @@
+package speechmatics
+
+import (
+	"testing"
+
+	"github.com/dreamtrans/backend/internal/config"
+)
+
+// Config validation accepts exactly the regions that have endpoints
+func TestConfigRegionsMatchKnownRegions(t *testing.T) {
+	if len(config.SpeechmaticsRegions) != len(knownRegions) {
+		t.Errorf("config lists regions %q, want the %d known regions", config.SpeechmaticsRegions, len(knownRegions))
+	}
+	for _, name := range config.SpeechmaticsRegions {
+		if _, ok := knownRegions[name]; !ok {
+			t.Errorf("config region %q has no endpoints", name)
+		}
+	}
+}
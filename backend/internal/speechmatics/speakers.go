@@ -0,0 +1,23 @@
+package speechmatics
+
+// SpeakerIdentifiers links a speaker label to the voice identifiers the
+// engine derived for that speaker. Identifiers returned for one session can
+// be passed to later sessions, which then label that speaker with Label
+// instead of a diarization label such as "S1".
+type SpeakerIdentifiers struct {
+	Label       string   `json:"label"`
+	Identifiers []string `json:"speaker_identifiers"`
+}
+
+// SpeakerDiarizationConfig enables speaker identification in batch jobs
+type SpeakerDiarizationConfig struct {
+	// Speakers are the known speakers to recognize
+	Speakers []SpeakerIdentifiers `json:"speakers,omitempty"`
+	// GetSpeakers returns the identifiers of the speakers in the job
+	GetSpeakers bool `json:"get_speakers,omitempty"`
+}
+
+// speakersMessage is the payload of SpeakersResult
+type speakersMessage struct {
+	Speakers []SpeakerIdentifiers `json:"speakers"`
+}
@@ -0,0 +1,66 @@
+package speechmatics
+
+import (
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// bytesPerSample is the size of one pcm_f32le mono sample
+const bytesPerSample = 4
+
+// sessionStats records Prometheus metrics for a single realtime session.
+// audio is called from the sender goroutine and transcript from the reader
+// goroutine, so the two never share fields.
+type sessionStats struct {
+	audioBytes   prometheus.Counter
+	audioSeconds prometheus.Counter
+	firstResult  prometheus.Observer
+	partialFinal prometheus.Observer
+
+	bytesPerSecond float64
+	started        time.Time
+	seenFirst      bool
+	partialStart   time.Time
+}
+
+// newSessionStats creates the metrics recorder for a session that starts now
+func newSessionStats(language string, settings config.TranscriptionConfig) *sessionStats {
+	language, engine := metrics.Language(language), metrics.Engine(settings.OperatingPoint)
+	return &sessionStats{
+		audioBytes:     metrics.AudioBytes.WithLabelValues(language, engine),
+		audioSeconds:   metrics.AudioSeconds.WithLabelValues(language, engine),
+		firstResult:    metrics.TimeToFirstTranscript.WithLabelValues(language, engine),
+		partialFinal:   metrics.PartialToFinalLatency.WithLabelValues(language, engine),
+		bytesPerSecond: float64(settings.SampleRate * bytesPerSample),
+		started:        time.Now(),
+	}
+}
+
+// audio records a chunk of audio sent upstream
+func (s *sessionStats) audio(n int) {
+	s.audioBytes.Add(float64(n))
+	s.audioSeconds.Add(float64(n) / s.bytesPerSecond)
+}
+
+// transcript records the arrival of a partial or final transcript
+func (s *sessionStats) transcript(final bool) {
+	now := time.Now()
+	if !s.seenFirst {
+		s.seenFirst = true
+		s.firstResult.Observe(now.Sub(s.started).Seconds())
+	}
+
+	if !final {
+		if s.partialStart.IsZero() {
+			s.partialStart = now
+		}
+		return
+	}
+	if !s.partialStart.IsZero() {
+		s.partialFinal.Observe(now.Sub(s.partialStart).Seconds())
+		s.partialStart = time.Time{}
+	}
+}
@@ -0,0 +1,16 @@
+package speechmatics
+
+// SummarizationConfig asks a batch job to summarize the transcript
+type SummarizationConfig struct {
+	// ContentType is "auto", "informative" or "conversational"
+	ContentType string `json:"content_type,omitempty"`
+	// SummaryLength is "brief" or "detailed"
+	SummaryLength string `json:"summary_length,omitempty"`
+	// SummaryType is "paragraphs" or "bullets"
+	SummaryType string `json:"summary_type,omitempty"`
+}
+
+// Summary is the summary of a batch transcript
+type Summary struct {
+	Content string `json:"content"`
+}
@@ -0,0 +1,94 @@
+package speechmatics
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceGap starts a new sentence when words are further apart than this (seconds)
+const sentenceGap = 1.5
+
+// Sentence is a run of words from one speaker, ending at sentence punctuation,
+// a speaker change or a pause
+type Sentence struct {
+	Speaker   string
+	Text      string
+	StartTime float64
+	EndTime   float64
+}
+
+// Language returns the transcript language
+func (t *TranscriptResponse) Language() string {
+	if t.Metadata.Language != "" {
+		return t.Metadata.Language
+	}
+	return t.Metadata.TranscriptionConfig.Language
+}
+
+// Sentences groups the word-level results of a json-v2 transcript into sentences
+func (t *TranscriptResponse) Sentences() []Sentence {
+	var (
+		sentences []Sentence
+		current   *Sentence
+		text      strings.Builder
+	)
+	flush := func() {
+		if current != nil && text.Len() > 0 {
+			current.Text = text.String()
+			sentences = append(sentences, *current)
+		}
+		current = nil
+		text.Reset()
+	}
+
+	for _, r := range t.Results {
+		if len(r.Alternatives) == 0 {
+			continue
+		}
+		alt := r.Alternatives[0]
+
+		if r.Type == "punctuation" {
+			if current == nil {
+				continue
+			}
+			text.WriteString(alt.Content)
+			current.EndTime = max(current.EndTime, r.EndTime)
+			if isSentenceEnd(alt.Content) {
+				flush()
+			}
+			continue
+		}
+
+		if current != nil && (alt.Speaker != current.Speaker || r.StartTime-current.EndTime > sentenceGap) {
+			flush()
+		}
+		if current == nil {
+			current = &Sentence{Speaker: alt.Speaker, StartTime: r.StartTime}
+		} else if needsSpace(text.String(), alt.Content) {
+			text.WriteByte(' ')
+		}
+		text.WriteString(alt.Content)
+		current.EndTime = r.EndTime
+	}
+	flush()
+	return sentences
+}
+
+// isSentenceEnd reports whether punctuation ends a sentence
+func isSentenceEnd(p string) bool {
+	return strings.ContainsAny(p, ".?!。？！")
+}
+
+// needsSpace reports whether words are separated by a space. Scripts without
+// word spacing, such as Chinese and Japanese, are joined directly.
+func needsSpace(prev, next string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+	return !isCJK(last) || !isCJK(first)
+}
+
+// isCJK reports whether r belongs to a script written without spaces
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
@@ -0,0 +1,15 @@
+package speechmatics
+
+// TranslationConfig asks a batch job to translate the transcript
+type TranslationConfig struct {
+	TargetLanguages []string `json:"target_languages"`
+}
+
+// TranslatedSentence is one sentence of a batch translation, with the timing
+// and speaker of the source sentence
+type TranslatedSentence struct {
+	Content   string  `json:"content"`
+	Speaker   string  `json:"speaker,omitempty"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
@@ -0,0 +1,203 @@
+package summarize
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/utterance"
+)
+
+// maxItems bounds the decisions and action items of a local summary
+const maxItems = 20
+
+var (
+	decisionCue = regexp.MustCompile(`(?i)\b(decided|decision|agreed|agree on|approved|settled on|let'?s go with|we'?ll go with|we will go with)\b|決定|決めました|合意`)
+	actionCue   = regexp.MustCompile(`(?i)\b(i'?ll|i will|i'?m going to|i am going to|we'?ll|we will|we need to|i need to|needs to|action item|to-?do|follow up|take care of|by (monday|tuesday|wednesday|thursday|friday|tomorrow|next week|end of (the )?(day|week)))\b|やります|対応します|確認します`)
+	// ownerCue matches a named owner such as "Alice will" or "Bob needs to"
+	ownerCue = regexp.MustCompile(`\b(\p{Lu}\p{L}+)(?: will|'ll| needs to| is going to| should)\b`)
+)
+
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`the and for are but not you all any can had her was one our out has have
+		him his how its let may new now see way who did get got she too use that this with from they them then
+		than there their what when where which will would could should about into just like also been were your
+		yes yeah okay well really think know going want need some more very much here over only because so
+		i'm it's that's we're you're don't let's i'll we'll`) {
+		stopWords[w] = true
+	}
+}
+
+// Local is a deterministic extractive summarizer. The summary is made of the
+// sentences with the most frequent content words, in spoken order; decisions
+// and action items are sentences with cue phrases such as "we agreed" or
+// "I will".
+type Local struct {
+	// MaxSentences bounds the length of the summary
+	MaxSentences int
+}
+
+// NewLocal creates a local summarizer with summaries of up to maxSentences
+func NewLocal(maxSentences int) *Local {
+	return &Local{MaxSentences: maxSentences}
+}
+
+// sentence is one sentence of the session with its score
+type sentence struct {
+	utterance.Utterance
+	words []string
+	score float64
+}
+
+// Summarize implements Summarizer
+func (l *Local) Summarize(ctx context.Context, s *sessions.Session) (*sessions.MeetingSummary, error) {
+	sentences := splitSession(s)
+	sum := &sessions.MeetingSummary{
+		Summary:     l.summary(sentences),
+		Decisions:   []string{},
+		ActionItems: []sessions.ActionItem{},
+		Summarizer:  "local",
+		CreatedAt:   time.Now().UTC(),
+	}
+	owners := speakerLabels(s)
+	for _, sen := range sentences {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if decisionCue.MatchString(sen.Text) && len(sum.Decisions) < maxItems {
+			sum.Decisions = append(sum.Decisions, sen.Text)
+		}
+		named, ok := namedOwner(sen, owners)
+		if (ok || actionCue.MatchString(sen.Text)) && len(sum.ActionItems) < maxItems {
+			if !ok {
+				named = sen.Speaker
+			}
+			sum.ActionItems = append(sum.ActionItems, sessions.ActionItem{
+				Text:      sen.Text,
+				Speaker:   named,
+				StartTime: sen.StartTime,
+			})
+		}
+	}
+	return sum, nil
+}
+
+// summary returns the highest scoring sentences in spoken order
+func (l *Local) summary(sentences []sentence) string {
+	freq := make(map[string]int)
+	for _, sen := range sentences {
+		for _, w := range sen.words {
+			freq[w]++
+		}
+	}
+	var ranked []int
+	for i := range sentences {
+		sen := &sentences[i]
+		if len(sen.words) < 3 {
+			continue
+		}
+		for _, w := range sen.words {
+			sen.score += float64(freq[w])
+		}
+		// Favour dense sentences over long ones
+		sen.score /= math.Sqrt(float64(len(sen.words)))
+		ranked = append(ranked, i)
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return sentences[ranked[a]].score > sentences[ranked[b]].score
+	})
+	if len(ranked) > l.MaxSentences {
+		ranked = ranked[:l.MaxSentences]
+	}
+	sort.Ints(ranked)
+
+	var b strings.Builder
+	for _, i := range ranked {
+		text := sentences[i].Text
+		if b.Len() > 0 && !isCJKText(text) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// splitSession splits the segments of a session into sentences
+func splitSession(s *sessions.Session) []sentence {
+	seg := utterance.NewSegmenter()
+	var utterances []utterance.Utterance
+	for _, segment := range s.Segments {
+		utterances = append(utterances, seg.Add(segment.Speaker, segment.Text, segment.StartTime, segment.EndTime)...)
+	}
+	utterances = append(utterances, seg.Flush()...)
+
+	sentences := make([]sentence, 0, len(utterances))
+	for _, u := range utterances {
+		u.Text = strings.TrimSpace(u.Text)
+		if u.Text == "" {
+			continue
+		}
+		sentences = append(sentences, sentence{Utterance: u, words: contentWords(u.Text)})
+	}
+	return sentences
+}
+
+// contentWords returns the lower-cased words of text without stop words.
+// Scripts written without spaces are split into character pairs.
+func contentWords(text string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	}) {
+		runes := []rune(strings.Trim(field, "'"))
+		if len(runes) > 0 && isCJK(runes[0]) {
+			for i := 0; i+1 < len(runes); i++ {
+				words = append(words, string(runes[i:i+2]))
+			}
+			continue
+		}
+		if len(runes) < 3 || stopWords[string(runes)] {
+			continue
+		}
+		words = append(words, string(runes))
+	}
+	return words
+}
+
+// speakerLabels maps the lower-cased speaker names of a session to labels
+func speakerLabels(s *sessions.Session) map[string]string {
+	labels := make(map[string]string, len(s.Speakers))
+	for label, name := range s.Speakers {
+		labels[strings.ToLower(name)] = label
+	}
+	return labels
+}
+
+// namedOwner returns the label of the speaker a sentence assigns a task to,
+// as in "Alice will send the notes"
+func namedOwner(sen sentence, labels map[string]string) (string, bool) {
+	for _, m := range ownerCue.FindAllStringSubmatch(sen.Text, -1) {
+		if label, ok := labels[strings.ToLower(m[1])]; ok {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+func isCJKText(text string) bool {
+	for _, r := range text {
+		return isCJK(r)
+	}
+	return false
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
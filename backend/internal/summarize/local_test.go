@@ -0,0 +1,121 @@
+package summarize
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dreamtrans/backend/internal/sessions"
+	"github.com/dreamtrans/backend/internal/utterance"
+)
+
+func TestCues(t *testing.T) {
+	tests := []struct {
+		text             string
+		decision, action bool
+	}{
+		{"We decided to ship on Friday.", true, false},
+		{"We agreed on the new pricing.", true, false},
+		{"Let's go with the blue design.", true, false},
+		{"The budget was approved.", true, false},
+		{"I'll send the notes.", false, true},
+		{"We need to fix the login page.", false, true},
+		{"Someone should follow up with legal.", false, true},
+		{"Please review it by Friday.", false, true},
+		{"We decided that I will write the spec.", true, true},
+		{"リリース日を決定しました。", true, false},
+		{"私が確認します。", false, true},
+		{"The weather was nice today.", false, false},
+		{"We disagreed about everything.", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := decisionCue.MatchString(tt.text); got != tt.decision {
+				t.Errorf("decision cue = %v, want %v", got, tt.decision)
+			}
+			if got := actionCue.MatchString(tt.text); got != tt.action {
+				t.Errorf("action cue = %v, want %v", got, tt.action)
+			}
+		})
+	}
+}
+
+func TestNamedOwner(t *testing.T) {
+	labels := map[string]string{"alice": "S1", "bob": "S2"}
+	tests := []struct {
+		text  string
+		label string
+		ok    bool
+	}{
+		{"Alice will send the notes.", "S1", true},
+		{"Bob needs to update the budget.", "S2", true},
+		{"Then Bob is going to call them.", "S2", true},
+		{"Alice'll book the room.", "S1", true},
+		{"Carol will review it.", "", false},
+		{"alice will send the notes.", "", false},
+		{"We will ask Alice later.", "", false},
+		{"Maybe Carol should, or Bob should.", "S2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			sen := sentence{Utterance: utterance.Utterance{Text: tt.text}}
+			label, ok := namedOwner(sen, labels)
+			if label != tt.label || ok != tt.ok {
+				t.Errorf("namedOwner = %q, %v, want %q, %v", label, ok, tt.label, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLocalSummarize(t *testing.T) {
+	s := &sessions.Session{
+		Speakers: map[string]string{"S1": "Alice", "S2": "Bob"},
+		Segments: []sessions.Segment{
+			{Speaker: "S1", Text: "The release checklist covers the release notes and the release date.", StartTime: 0, EndTime: 4},
+			{Speaker: "S2", Text: "We agreed to move the release to Friday.", StartTime: 4, EndTime: 7},
+			{Speaker: "S1", Text: "I'll update the release checklist.", StartTime: 7, EndTime: 9},
+			{Speaker: "S2", Text: "Alice will email the customers.", StartTime: 9, EndTime: 11},
+			{Speaker: "S2", Text: "Nice weather today.", StartTime: 11, EndTime: 12},
+		},
+	}
+	sum, err := NewLocal(2).Summarize(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Summarize error: %v", err)
+	}
+
+	if want := []string{"We agreed to move the release to Friday."}; strings.Join(sum.Decisions, "|") != strings.Join(want, "|") {
+		t.Errorf("decisions = %q, want %q", sum.Decisions, want)
+	}
+	wantItems := []sessions.ActionItem{
+		{Text: "I'll update the release checklist.", Speaker: "S1", StartTime: 7},
+		// Named owners take precedence over the speaker
+		{Text: "Alice will email the customers.", Speaker: "S1", StartTime: 9},
+	}
+	if len(sum.ActionItems) != len(wantItems) {
+		t.Fatalf("action items = %+v, want %+v", sum.ActionItems, wantItems)
+	}
+	for i, want := range wantItems {
+		if sum.ActionItems[i] != want {
+			t.Errorf("action item %d = %+v, want %+v", i, sum.ActionItems[i], want)
+		}
+	}
+
+	if strings.Contains(sum.Summary, "weather") {
+		t.Errorf("summary %q contains the low scoring sentence", sum.Summary)
+	}
+	if !strings.HasPrefix(sum.Summary, "The release checklist") {
+		t.Errorf("summary %q does not keep spoken order", sum.Summary)
+	}
+	if sum.Summarizer != "local" {
+		t.Errorf("summarizer = %q, want local", sum.Summarizer)
+	}
+
+	// The summary is deterministic apart from its creation time
+	again, err := NewLocal(2).Summarize(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Summarize error: %v", err)
+	}
+	if again.Summary != sum.Summary {
+		t.Errorf("second summary %q differs from %q", again.Summary, sum.Summary)
+	}
+}
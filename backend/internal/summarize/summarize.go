@@ -0,0 +1,53 @@
+// Package summarize produces meeting summaries with decisions and action
+// items from stored sessions
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/sessions"
+)
+
+// Summarizer summarizes the segments of a session
+type Summarizer interface {
+	Summarize(ctx context.Context, s *sessions.Session) (*sessions.MeetingSummary, error)
+}
+
+// New creates the summarizer selected by the configuration. It returns nil
+// when summaries are disabled.
+func New(cfg config.SummaryConfig) (Summarizer, error) {
+	switch cfg.Summarizer {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocal(cfg.MaxSentences), nil
+	default:
+		return nil, fmt.Errorf("unknown summarizer %q", cfg.Summarizer)
+	}
+}
+
+// Session summarizes the stored session id and saves the summary with it
+func Session(ctx context.Context, sum Summarizer, store *sessions.Store, id string) (*sessions.MeetingSummary, error) {
+	s, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := sum.Summarize(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("summarize session: %w", err)
+	}
+	if summary.CreatedAt.IsZero() {
+		summary.CreatedAt = time.Now().UTC()
+	}
+	s, err = store.Update(id, func(s *sessions.Session) error {
+		s.MeetingSummary = summary
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.PresentSummary(), nil
+}
@@ -0,0 +1,191 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+)
+
+// Enabled reports whether TLS is configured
+func Enabled(cfg config.TLSConfig) bool {
+	return cfg.CertFile != ""
+}
+
+// reloader keeps the server certificate and client CA pool in sync with the
+// files on disk
+type reloader struct {
+	cfg    config.TLSConfig
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewServerConfig builds a TLS configuration from cfg. Certificates are
+// reloaded automatically when the files change on disk until ctx is canceled.
+// When a client CA is configured, clients must present a certificate signed by
+// it, and if AllowedSANs is set the certificate must carry one of those names.
+func NewServerConfig(ctx context.Context, cfg config.TLSConfig, logger *slog.Logger) (*tls.Config, error) {
+	r := &reloader{cfg: cfg, logger: logger, modTimes: make(map[string]time.Time)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	go r.watch(ctx)
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+
+	if cfg.ClientCAFile != "" {
+		// GetConfigForClient lets every handshake use the latest client CA pool
+		base := tlsConfig.Clone()
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+		base.VerifyConnection = r.verifySAN
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			r.mu.RLock()
+			c.ClientCAs = r.clientCA
+			r.mu.RUnlock()
+			return c, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// load reads the certificate, key and client CA files
+func (r *reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = modTimes
+	return nil
+}
+
+// statFiles returns the modification time of every configured file
+func (r *reloader) statFiles() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// changed reports whether any file was modified since the last load
+func (r *reloader) changed() bool {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		r.logger.Warn("Failed to check TLS files for changes", "error", err)
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for file, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch polls the files and reloads them when they change. A failed reload
+// keeps the previous certificates so that a half-written rotation does not
+// break new handshakes.
+func (r *reloader) watch(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				r.logger.Error("Failed to reload TLS certificates, keeping previous ones", "error", err)
+				continue
+			}
+			r.logger.Info("TLS certificates reloaded", "cert_file", r.cfg.CertFile)
+		}
+	}
+}
+
+// verifySAN checks the client certificate against the allowed SAN list
+func (r *reloader) verifySAN(cs tls.ConnectionState) error {
+	if len(r.cfg.AllowedSANs) == 0 {
+		return nil
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("client certificate required")
+	}
+
+	leaf := cs.PeerCertificates[0]
+	names := append([]string{}, leaf.DNSNames...)
+	names = append(names, leaf.EmailAddresses...)
+	for _, ip := range leaf.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range leaf.URIs {
+		names = append(names, uri.String())
+	}
+
+	for _, allowed := range r.cfg.AllowedSANs {
+		for _, name := range names {
+			if ok, _ := path.Match(allowed, name); ok {
+				return nil
+			}
+		}
+	}
+
+	r.logger.Warn("Rejected client certificate", "subject", leaf.Subject.String(), "sans", names)
+	return fmt.Errorf("client certificate SAN not allowed")
+}
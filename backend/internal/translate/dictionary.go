@@ -0,0 +1,116 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// maxPhraseWords is the longest phrase looked up in a dictionary
+const maxPhraseWords = 4
+
+// Dictionary is a local stand-in for a translation service. It replaces
+// known words and phrases using per-language tables and keeps unknown words
+// as they are, so the output is predictable in development and tests.
+type Dictionary struct {
+	// tables maps a target language to lower case phrases and their translation
+	tables map[string]map[string]string
+}
+
+// NewDictionary creates a dictionary from phrase tables keyed by target
+// language, such as {"ja": {"good morning": "おはようございます"}}
+func NewDictionary(tables map[string]map[string]string) *Dictionary {
+	d := &Dictionary{tables: make(map[string]map[string]string, len(tables))}
+	for lang, phrases := range tables {
+		t := make(map[string]string, len(phrases))
+		for phrase, translation := range phrases {
+			t[strings.ToLower(strings.Join(strings.Fields(phrase), " "))] = translation
+		}
+		d.tables[strings.ToLower(lang)] = t
+	}
+	return d
+}
+
+// LoadDictionary reads the phrase tables from a JSON file. An empty path
+// gives a dictionary without languages.
+func LoadDictionary(path string) (*Dictionary, error) {
+	if path == "" {
+		return NewDictionary(nil), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	var tables map[string]map[string]string
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("failed to parse dictionary: %w", err)
+	}
+	return NewDictionary(tables), nil
+}
+
+// Translate replaces the longest known phrase at each word, keeping the
+// punctuation around it
+func (d *Dictionary) Translate(_ context.Context, text, _, target string) (string, error) {
+	table, ok := d.tables[strings.ToLower(target)]
+	if !ok {
+		return "", fmt.Errorf("%w: no dictionary for %q", ErrUnsupported, target)
+	}
+
+	words := strings.Fields(text)
+	out := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		n, translation := d.lookup(table, words[i:])
+		if n == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+		lead, _ := splitPunct(words[i])
+		_, trail := splitPunct(words[i+n-1])
+		out = append(out, lead+translation+trail)
+		i += n
+	}
+	return strings.Join(out, " "), nil
+}
+
+// lookup returns the number of words of the longest phrase at the start of
+// words that is in the table, and its translation. Phrases do not span
+// punctuation between words, so "good, morning" is not "good morning".
+func (d *Dictionary) lookup(table map[string]string, words []string) (int, string) {
+	longest := min(len(words), maxPhraseWords)
+	for i := 0; i < longest-1; i++ {
+		_, trail := splitPunct(words[i])
+		lead, _ := splitPunct(words[i+1])
+		if trail != "" || lead != "" {
+			longest = i + 1
+			break
+		}
+	}
+	for n := longest; n > 0; n-- {
+		parts := make([]string, n)
+		for i, w := range words[:n] {
+			parts[i] = strings.ToLower(strings.TrimFunc(w, isPunct))
+		}
+		if translation, ok := table[strings.Join(parts, " ")]; ok {
+			return n, translation
+		}
+	}
+	return 0, ""
+}
+
+// splitPunct returns the punctuation before and after a word
+func splitPunct(word string) (lead, trail string) {
+	core := strings.TrimFunc(word, isPunct)
+	if core == "" {
+		return word, ""
+	}
+	i := strings.Index(word, core)
+	return word[:i], word[i+len(core):]
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
@@ -0,0 +1,52 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDictionaryTranslate(t *testing.T) {
+	d := NewDictionary(map[string]map[string]string{
+		"JA": {
+			"good morning":  "おはようございます",
+			"good":          "良い",
+			"thank you":     "ありがとう",
+			"Meeting  Room": "会議室",
+		},
+	})
+
+	tests := []struct {
+		name, text, want string
+	}{
+		{"word", "good", "良い"},
+		{"longest phrase wins", "good morning everyone", "おはようございます everyone"},
+		{"case insensitive", "Good Morning", "おはようございます"},
+		{"phrase keys are normalized", "the meeting room", "the 会議室"},
+		{"trailing punctuation", "thank you!", "ありがとう!"},
+		{"leading punctuation", "(good)", "(良い)"},
+		{"punctuation inside a phrase", "good, morning", "良い, morning"},
+		{"unknown words kept", "see you tomorrow", "see you tomorrow"},
+		{"whitespace collapsed", "  good   morning  ", "おはようございます"},
+		{"punctuation only", "...", "..."},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.Translate(context.Background(), tt.text, "en", "ja")
+			if err != nil {
+				t.Fatalf("Translate(%q) error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("Translate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDictionaryUnknownLanguage(t *testing.T) {
+	d := NewDictionary(map[string]map[string]string{"ja": {"good": "良い"}})
+	if _, err := d.Translate(context.Background(), "good", "en", "fr"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Translate into fr error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,133 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HTTP translates with a LibreTranslate compatible service, which accepts
+// {"q", "source", "target", "format", "api_key"} and returns
+// {"translatedText"}. q may be a list, which translates many texts in one
+// request.
+type HTTP struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTP creates a client for the service at url. timeout bounds each
+// request.
+func NewHTTP(url, apiKey string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+// Translate translates one text
+func (h *HTTP) Translate(ctx context.Context, text, source, target string) (string, error) {
+	var resp struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := h.post(ctx, text, source, target, &resp); err != nil {
+		return "", err
+	}
+	return resp.TranslatedText, nil
+}
+
+// TranslateAll translates many texts in one request
+func (h *HTTP) TranslateAll(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := h.post(ctx, texts, source, target, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("translation service returned %d texts for %d", len(resp.TranslatedText), len(texts))
+	}
+	return resp.TranslatedText, nil
+}
+
+// libreLanguages maps the Speechmatics language codes used throughout
+// DreamTrans to LibreTranslate's codes where they differ
+var libreLanguages = map[string]string{
+	"cmn": "zh",
+}
+
+// libreLanguage returns the LibreTranslate code of a Speechmatics language
+func libreLanguage(code string) string {
+	if libre, ok := libreLanguages[code]; ok {
+		return libre
+	}
+	return code
+}
+
+// post sends a translation request and decodes the response into out
+func (h *HTTP) post(ctx context.Context, q interface{}, source, target string, out interface{}) error {
+	if source == "" {
+		source = "auto"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"q":       q,
+		"source":  libreLanguage(source),
+		"target":  libreLanguage(target),
+		"format":  "text",
+		"api_key": h.apiKey,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create translation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send translation request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read translation response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest && unsupportedLanguage(data):
+		return fmt.Errorf("%w: %s", ErrUnsupported, bytes.TrimSpace(data))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("translation service error (status %d): %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse translation response: %w", err)
+	}
+	return nil
+}
+
+// unsupportedLanguage reports whether a 400 response body is LibreTranslate's
+// error for an unknown language or language pair. Other bad requests, such
+// as a text over the length limit, are ordinary failures.
+func unsupportedLanguage(body []byte) bool {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	return strings.Contains(resp.Error, "is not supported") ||
+		strings.Contains(resp.Error, "does not support translation")
+}
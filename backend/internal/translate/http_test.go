@@ -0,0 +1,113 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		unsupported bool
+	}{
+		{"unknown target", http.StatusBadRequest, `{"error":"xx is not supported"}`, true},
+		{"unknown pair", http.StatusBadRequest, `{"error":"en (English) does not support translation to xx"}`, true},
+		{"text too long", http.StatusBadRequest, `{"error":"Invalid request: request (6000) exceeds text limit (5000)"}`, false},
+		{"missing text", http.StatusBadRequest, `{"error":"Invalid request: missing q parameter"}`, false},
+		{"not json", http.StatusBadRequest, `Bad Request`, false},
+		{"bad api key", http.StatusForbidden, `{"error":"Invalid API key"}`, false},
+		{"server error", http.StatusInternalServerError, `{"error":"xx is not supported"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := NewHTTP(srv.URL, "", time.Second).Translate(context.Background(), "hello", "en", "xx")
+			if err == nil {
+				t.Fatal("Translate succeeded")
+			}
+			if got := errors.Is(err, ErrUnsupported); got != tt.unsupported {
+				t.Errorf("Translate error %v: unsupported = %v, want %v", err, got, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestHTTPLanguageCodes(t *testing.T) {
+	tests := []struct {
+		source, target         string
+		wantSource, wantTarget string
+	}{
+		{"en", "cmn", "en", "zh"},
+		{"cmn", "en", "zh", "en"},
+		{"", "ja", "auto", "ja"},
+		{"de", "fr", "de", "fr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source+"-"+tt.target, func(t *testing.T) {
+			var req struct {
+				Q      string `json:"q"`
+				Source string `json:"source"`
+				Target string `json:"target"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				w.Write([]byte(`{"translatedText":"translated"}`))
+			}))
+			defer srv.Close()
+
+			got, err := NewHTTP(srv.URL, "", time.Second).Translate(context.Background(), "hello", tt.source, tt.target)
+			if err != nil || got != "translated" {
+				t.Fatalf("Translate = %q, %v", got, err)
+			}
+			if req.Q != "hello" || req.Source != tt.wantSource || req.Target != tt.wantTarget {
+				t.Errorf("request %+v, want source %q and target %q", req, tt.wantSource, tt.wantTarget)
+			}
+		})
+	}
+}
+
+// TestHTTPSegmentsLanguageCodes checks the batch path, which translates a
+// transcript's segments in TranslateAll requests
+func TestHTTPSegmentsLanguageCodes(t *testing.T) {
+	var req struct {
+		Q      []string `json:"q"`
+		Source string   `json:"source"`
+		Target string   `json:"target"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		out := make([]string, len(req.Q))
+		for i, q := range req.Q {
+			out[i] = strings.ToUpper(q)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"translatedText": out})
+	}))
+	defer srv.Close()
+
+	in := segments(3)
+	out, err := Segments(context.Background(), NewHTTP(srv.URL, "", time.Second), in, "en", "cmn")
+	if err != nil {
+		t.Fatalf("Segments error: %v", err)
+	}
+	if req.Source != "en" || req.Target != "zh" || len(req.Q) != 3 {
+		t.Errorf("request %+v, want 3 texts from en to zh", req)
+	}
+	checkTranslated(t, in, out)
+}
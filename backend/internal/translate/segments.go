@@ -0,0 +1,58 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+)
+
+// batchSize bounds the texts sent in one request to backends that
+// translate many texts at once
+const batchSize = 50
+
+// Segment is a timed piece of a transcript. Translating a segment keeps its
+// speaker and timing, so translated subtitles line up with the audio.
+type Segment struct {
+	Speaker   string  `json:"speaker,omitempty"`
+	Text      string  `json:"text"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// BatchTranslator is implemented by backends that translate many texts in
+// one request
+type BatchTranslator interface {
+	TranslateAll(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// Segments translates the text of each segment into target
+func Segments(ctx context.Context, t Translator, segments []Segment, source, target string) ([]Segment, error) {
+	out := make([]Segment, len(segments))
+	copy(out, segments)
+
+	if bt, ok := t.(BatchTranslator); ok {
+		for start := 0; start < len(out); start += batchSize {
+			end := min(start+batchSize, len(out))
+			texts := make([]string, 0, end-start)
+			for _, seg := range out[start:end] {
+				texts = append(texts, seg.Text)
+			}
+			translated, err := bt.TranslateAll(ctx, texts, source, target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate into %s: %w", target, err)
+			}
+			for i, text := range translated {
+				out[start+i].Text = text
+			}
+		}
+		return out, nil
+	}
+
+	for i := range out {
+		text, err := t.Translate(ctx, out[i].Text, source, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to translate into %s: %w", target, err)
+		}
+		out[i].Text = text
+	}
+	return out, nil
+}
@@ -0,0 +1,115 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// upper translates by upper-casing, one text at a time
+type upper struct {
+	calls int
+}
+
+func (u *upper) Translate(_ context.Context, text, _, _ string) (string, error) {
+	u.calls++
+	return strings.ToUpper(text), nil
+}
+
+// batchUpper translates by upper-casing and records the size of each batch
+type batchUpper struct {
+	upper
+	batches []int
+	err     error
+}
+
+func (b *batchUpper) TranslateAll(_ context.Context, texts []string, _, _ string) ([]string, error) {
+	b.batches = append(b.batches, len(texts))
+	if b.err != nil {
+		return nil, b.err
+	}
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = strings.ToUpper(text)
+	}
+	return out, nil
+}
+
+func segments(n int) []Segment {
+	segs := make([]Segment, n)
+	for i := range segs {
+		segs[i] = Segment{Speaker: "S1", Text: fmt.Sprintf("segment %d", i), StartTime: float64(i), EndTime: float64(i) + 0.5}
+	}
+	return segs
+}
+
+func TestSegmentsBatching(t *testing.T) {
+	tests := []struct {
+		segments int
+		batches  []int
+	}{
+		{0, nil},
+		{1, []int{1}},
+		{batchSize, []int{batchSize}},
+		{batchSize + 1, []int{batchSize, 1}},
+		{2*batchSize + 20, []int{batchSize, batchSize, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.segments), func(t *testing.T) {
+			bt := &batchUpper{}
+			in := segments(tt.segments)
+			out, err := Segments(context.Background(), bt, in, "en", "ja")
+			if err != nil {
+				t.Fatalf("Segments error: %v", err)
+			}
+			if fmt.Sprint(bt.batches) != fmt.Sprint(tt.batches) {
+				t.Errorf("batches = %v, want %v", bt.batches, tt.batches)
+			}
+			if bt.calls != 0 {
+				t.Errorf("Translate called %d times for a batch translator", bt.calls)
+			}
+			checkTranslated(t, in, out)
+		})
+	}
+}
+
+func TestSegmentsOneByOne(t *testing.T) {
+	u := &upper{}
+	in := segments(3)
+	out, err := Segments(context.Background(), u, in, "en", "ja")
+	if err != nil {
+		t.Fatalf("Segments error: %v", err)
+	}
+	if u.calls != 3 {
+		t.Errorf("Translate called %d times, want 3", u.calls)
+	}
+	checkTranslated(t, in, out)
+}
+
+func TestSegmentsError(t *testing.T) {
+	bt := &batchUpper{err: ErrUnsupported}
+	if _, err := Segments(context.Background(), bt, segments(2), "en", "xx"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Segments error = %v, want ErrUnsupported", err)
+	}
+}
+
+// checkTranslated checks that out translates in and keeps speakers and
+// timing, and that in is unchanged
+func checkTranslated(t *testing.T, in, out []Segment) {
+	t.Helper()
+	if len(out) != len(in) {
+		t.Fatalf("got %d segments, want %d", len(out), len(in))
+	}
+	for i := range in {
+		want := in[i]
+		want.Text = strings.ToUpper(in[i].Text)
+		if out[i] != want {
+			t.Errorf("segment %d = %+v, want %+v", i, out[i], want)
+		}
+		if in[i].Text != fmt.Sprintf("segment %d", i) {
+			t.Errorf("input segment %d was modified: %q", i, in[i].Text)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package translate provides the machine translation backends used for
+// languages the transcription engine does not translate itself
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dreamtrans/backend/internal/config"
+)
+
+// ErrUnsupported is returned for language pairs a backend cannot translate
+var ErrUnsupported = errors.New("unsupported language")
+
+// Translator translates text from the source language into the target
+// language. Languages are ISO codes such as "en" or "ja"; source may be
+// empty when it is not known.
+type Translator interface {
+	Translate(ctx context.Context, text, source, target string) (string, error)
+}
+
+// New creates the backend selected by the configuration. It returns nil when
+// translation is disabled or done by Speechmatics, which translates batch
+// jobs itself.
+func New(cfg config.TranslationConfig) (Translator, error) {
+	switch cfg.Backend {
+	case "", "speechmatics":
+		return nil, nil
+	case "dictionary":
+		return LoadDictionary(cfg.DictionaryFile)
+	case "http":
+		return NewHTTP(cfg.URL, cfg.APIKey, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown translation backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,192 @@
+// Package utterance assembles realtime final transcripts, which arrive in
+// chunks determined by the engine's max delay, into sentences and
+// utterances that clients can render as lines
+package utterance
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxGap ends an utterance when the next words start more than this
+// many seconds after it
+const DefaultMaxGap = 1.5
+
+// Reasons an utterance ended
+const (
+	ReasonPunctuation    = "punctuation"
+	ReasonSpeakerChange  = "speaker_change"
+	ReasonPause          = "pause"
+	ReasonEndOfUtterance = "end_of_utterance"
+	ReasonFlush          = "flush"
+)
+
+// Utterance is a complete sentence or utterance of one speaker
+type Utterance struct {
+	Speaker   string  `json:"speaker,omitempty"`
+	Text      string  `json:"text"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	// Reason is why the utterance ended
+	Reason string `json:"reason"`
+}
+
+// Segmenter collects final transcript chunks of one stream into utterances.
+// An utterance ends at sentence punctuation, a speaker change, a pause
+// longer than MaxGap, an end of utterance signal from the engine or a
+// flush. It is not safe for concurrent use. A nil Segmenter completes no
+// utterances.
+type Segmenter struct {
+	// MaxGap is the longest pause in seconds within an utterance
+	MaxGap float64
+
+	current *Utterance
+	text    strings.Builder
+}
+
+// NewSegmenter creates a segmenter with the default pause length
+func NewSegmenter() *Segmenter {
+	return &Segmenter{MaxGap: DefaultMaxGap}
+}
+
+// Add appends a final transcript chunk and returns the utterances it
+// completed. A chunk holding several sentences is split at sentence
+// punctuation, with times interpolated by text length.
+func (s *Segmenter) Add(speaker, text string, start, end float64) []Utterance {
+	text = strings.TrimSpace(text)
+	if s == nil || text == "" {
+		return nil
+	}
+
+	var done []Utterance
+	if s.current != nil {
+		switch {
+		case speaker != s.current.Speaker:
+			done = s.close(done, ReasonSpeakerChange)
+		case start-s.current.EndTime > s.MaxGap:
+			done = s.close(done, ReasonPause)
+		}
+	}
+
+	total := utf8.RuneCountInString(text)
+	offset := 0
+	for _, part := range splitSentences(text) {
+		n := utf8.RuneCountInString(part)
+		partStart := interpolate(start, end, offset, total)
+		offset += n
+		partEnd := interpolate(start, end, offset, total)
+
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		if s.current == nil {
+			s.current = &Utterance{Speaker: speaker, StartTime: partStart}
+		} else if needsSpace(s.text.String(), part) {
+			s.text.WriteByte(' ')
+		}
+		s.text.WriteString(part)
+		s.current.EndTime = partEnd
+		if endsSentence(part) {
+			done = s.close(done, ReasonPunctuation)
+		}
+	}
+	return done
+}
+
+// EndOfUtterance ends the current utterance when the engine detected the
+// end of an utterance
+func (s *Segmenter) EndOfUtterance() []Utterance {
+	return s.close(nil, ReasonEndOfUtterance)
+}
+
+// Flush ends the current utterance at the end of the stream
+func (s *Segmenter) Flush() []Utterance {
+	return s.close(nil, ReasonFlush)
+}
+
+// close appends the current utterance, if any, to done
+func (s *Segmenter) close(done []Utterance, reason string) []Utterance {
+	if s == nil || s.current == nil {
+		return done
+	}
+	u := *s.current
+	u.Text = s.text.String()
+	u.Reason = reason
+	s.current = nil
+	s.text.Reset()
+	return append(done, u)
+}
+
+// interpolate returns the time at rune offset of total runes between start
+// and end, rounded to milliseconds
+func interpolate(start, end float64, offset, total int) float64 {
+	t := start + (end-start)*float64(offset)/float64(total)
+	return math.Round(t*1000) / 1000
+}
+
+// splitSentences splits text after each run of sentence punctuation. Latin
+// punctuation only ends a sentence before whitespace or the end of the
+// text, so that decimals such as "3.50" and domains such as "example.com"
+// stay whole; full-width punctuation is not followed by spaces.
+func splitSentences(text string) []string {
+	var parts []string
+	start := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if !isSentenceEnd(runes[i]) {
+			continue
+		}
+		fullWidth := isCJK(runes[i])
+		for i+1 < len(runes) && (isSentenceEnd(runes[i+1]) || isClosing(runes[i+1])) {
+			i++
+		}
+		// Straight quotes close a sentence when nothing follows them
+		for i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\'') && (i+2 == len(runes) || unicode.IsSpace(runes[i+2])) {
+			i++
+		}
+		if !fullWidth && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		parts = append(parts, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if start < len(runes) {
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
+
+// endsSentence reports whether text ends with sentence punctuation,
+// possibly followed by closing quotes or brackets
+func endsSentence(text string) bool {
+	text = strings.TrimRightFunc(text, func(r rune) bool { return isClosing(r) || r == '"' || r == '\'' })
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return isSentenceEnd(last)
+}
+
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune(".?!。？！", r)
+}
+
+// isClosing reports whether r closes a quote or bracket. Straight quotes
+// are left out because they also open quotes.
+func isClosing(r rune) bool {
+	return strings.ContainsRune(")]”’」』", r)
+}
+
+// needsSpace reports whether text is joined with a space. Scripts without
+// word spacing, such as Chinese and Japanese, are joined directly.
+func needsSpace(prev, next string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+	return !isCJK(last) || !isCJK(first)
+}
+
+// isCJK reports whether r belongs to a script written without spaces,
+// including its punctuation
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef)
+}
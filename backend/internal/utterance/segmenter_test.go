@@ -0,0 +1,114 @@
+package utterance
+
+import (
+	"fmt"
+	"testing"
+)
+
+// chunk is a final transcript chunk
+type chunk struct {
+	speaker    string
+	text       string
+	start, end float64
+}
+
+func TestSegmenterAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []chunk
+		want   []Utterance
+	}{
+		{
+			name:   "decimal and domain",
+			chunks: []chunk{{"S1", "It costs $3.50 per user, see example.com for details.", 0, 4}},
+			want:   []Utterance{{Speaker: "S1", Text: "It costs $3.50 per user, see example.com for details.", StartTime: 0, EndTime: 4, Reason: ReasonPunctuation}},
+		},
+		{
+			name:   "domain",
+			chunks: []chunk{{"S1", "See example.com for details. Thanks!", 0, 3.6}},
+			want: []Utterance{
+				{Speaker: "S1", Text: "See example.com for details.", StartTime: 0, EndTime: 2.8, Reason: ReasonPunctuation},
+				{Speaker: "S1", Text: "Thanks!", StartTime: 2.8, EndTime: 3.6, Reason: ReasonPunctuation},
+			},
+		},
+		{
+			name:   "closing quote",
+			chunks: []chunk{{"S1", `He said "stop." Then left.`, 0, 2.6}},
+			want: []Utterance{
+				{Speaker: "S1", Text: `He said "stop."`, StartTime: 0, EndTime: 1.5, Reason: ReasonPunctuation},
+				{Speaker: "S1", Text: "Then left.", StartTime: 1.5, EndTime: 2.6, Reason: ReasonPunctuation},
+			},
+		},
+		{
+			name:   "CJK full stops",
+			chunks: []chunk{{"S1", "今日は晴れ。明日は雨？", 0, 2.2}},
+			want: []Utterance{
+				{Speaker: "S1", Text: "今日は晴れ。", StartTime: 0, EndTime: 1.2, Reason: ReasonPunctuation},
+				{Speaker: "S1", Text: "明日は雨？", StartTime: 1.2, EndTime: 2.2, Reason: ReasonPunctuation},
+			},
+		},
+		{
+			name:   "CJK chunks joined without spaces",
+			chunks: []chunk{{"S1", "今日は", 0, 1}, {"S1", "晴れ。", 1, 2}},
+			want:   []Utterance{{Speaker: "S1", Text: "今日は晴れ。", StartTime: 0, EndTime: 2, Reason: ReasonPunctuation}},
+		},
+		{
+			name:   "sentence across chunks",
+			chunks: []chunk{{"S1", "the price is", 0, 1}, {"S1", "3.50 dollars.", 1, 2}},
+			want:   []Utterance{{Speaker: "S1", Text: "the price is 3.50 dollars.", StartTime: 0, EndTime: 2, Reason: ReasonPunctuation}},
+		},
+		{
+			name:   "speaker change",
+			chunks: []chunk{{"S1", "hello", 0, 1}, {"S2", "hi.", 1, 2}},
+			want: []Utterance{
+				{Speaker: "S1", Text: "hello", StartTime: 0, EndTime: 1, Reason: ReasonSpeakerChange},
+				{Speaker: "S2", Text: "hi.", StartTime: 1, EndTime: 2, Reason: ReasonPunctuation},
+			},
+		},
+		{
+			name:   "pause",
+			chunks: []chunk{{"S1", "hello", 0, 1}, {"S1", "again.", 5, 6}},
+			want: []Utterance{
+				{Speaker: "S1", Text: "hello", StartTime: 0, EndTime: 1, Reason: ReasonPause},
+				{Speaker: "S1", Text: "again.", StartTime: 5, EndTime: 6, Reason: ReasonPunctuation},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSegmenter()
+			var got []Utterance
+			for _, c := range tt.chunks {
+				got = append(got, s.Add(c.speaker, c.text, c.start, c.end)...)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("utterances\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmenterEndOfUtterance(t *testing.T) {
+	s := NewSegmenter()
+	if got := s.Add("S1", "so we will", 0, 1); len(got) != 0 {
+		t.Fatalf("incomplete sentence completed %+v", got)
+	}
+	s.Add("S1", "see", 1, 1.5)
+	want := Utterance{Speaker: "S1", Text: "so we will see", StartTime: 0, EndTime: 1.5, Reason: ReasonEndOfUtterance}
+	if got := s.EndOfUtterance(); len(got) != 1 || got[0] != want {
+		t.Errorf("EndOfUtterance = %+v, want %+v", got, want)
+	}
+	if got := s.EndOfUtterance(); len(got) != 0 {
+		t.Errorf("second EndOfUtterance = %+v, want nothing", got)
+	}
+
+	s.Add("S1", "bye", 2, 3)
+	if got := s.Flush(); len(got) != 1 || got[0].Reason != ReasonFlush || got[0].Text != "bye" {
+		t.Errorf("Flush = %+v, want bye", got)
+	}
+
+	var nilSegmenter *Segmenter
+	if got := nilSegmenter.Add("S1", "hello.", 0, 1); got != nil {
+		t.Errorf("nil segmenter completed %+v", got)
+	}
+}
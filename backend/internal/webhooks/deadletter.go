@@ -0,0 +1,158 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for unknown dead letters
+var ErrNotFound = errors.New("dead letter not found")
+
+// validID matches delivery IDs, which are used as file names
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// DeadLetter is an event that could not be delivered to a sink
+type DeadLetter struct {
+	ID        string          `json:"id"`
+	Sink      string          `json:"sink"`
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Event     json.RawMessage `json:"event"`
+	Error     string          `json:"error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// deadLetters stores dead letters as one JSON file per delivery
+type deadLetters struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func newDeadLetters(dir string) (*deadLetters, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create dead letter directory: %w", err)
+	}
+	return &deadLetters{dir: dir}, nil
+}
+
+func (q *deadLetters) path(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
+
+// add stores a failed delivery
+func (q *deadLetters) add(dl *delivery, cause error) error {
+	// Not indented, so that the event is redelivered byte for byte
+	data, err := json.Marshal(DeadLetter{
+		ID:        dl.ID,
+		Sink:      dl.Sink,
+		EventID:   dl.EventID,
+		EventType: dl.EventType,
+		Event:     dl.Body,
+		Error:     cause.Error(),
+		FailedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tmp := q.path(dl.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return os.Rename(tmp, q.path(dl.ID))
+}
+
+// get returns a dead letter
+func (q *deadLetters) get(id string) (*DeadLetter, error) {
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(q.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read dead letter: %w", err)
+	}
+	var dl DeadLetter
+	if err := json.Unmarshal(data, &dl); err != nil {
+		return nil, fmt.Errorf("failed to parse dead letter %s: %w", id, err)
+	}
+	return &dl, nil
+}
+
+// remove deletes a dead letter
+func (q *deadLetters) remove(id string) error {
+	if !validID.MatchString(id) {
+		return ErrNotFound
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := os.Remove(q.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeadLetters returns the events that could not be delivered, most recent
+// first, optionally filtered by sink
+func (d *Dispatcher) DeadLetters(sinkName string) ([]DeadLetter, error) {
+	entries, err := os.ReadDir(d.dead.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	letters := []DeadLetter{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		dl, err := d.dead.get(id)
+		if errors.Is(err, ErrNotFound) {
+			// Redelivered since ReadDir
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if sinkName == "" || dl.Sink == sinkName {
+			letters = append(letters, *dl)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt.After(letters[j].FailedAt)
+	})
+	return letters, nil
+}
+
+// Redeliver queues a dead letter for delivery to its sink again and removes
+// it from the dead letter queue
+func (d *Dispatcher) Redeliver(id string) error {
+	dl, err := d.dead.get(id)
+	if err != nil {
+		return err
+	}
+	s, err := d.sink(dl.Sink)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.queue <- &delivery{ID: dl.ID, Sink: dl.Sink, EventID: dl.EventID, EventType: dl.EventType, Body: dl.Event}:
+	default:
+		return fmt.Errorf("webhook queue of sink %q is full", s.Name)
+	}
+	return d.dead.remove(id)
+}
+
+// DeleteDeadLetter discards a dead letter
+func (d *Dispatcher) DeleteDeadLetter(id string) error {
+	return d.dead.remove(id)
+}
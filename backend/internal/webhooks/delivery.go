@@ -0,0 +1,202 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/metrics"
+)
+
+// Headers sent with every delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers
+// should reject deliveries with an old timestamp.
+const (
+	HeaderEvent     = "X-DreamTrans-Event"
+	HeaderDelivery  = "X-DreamTrans-Delivery"
+	HeaderTimestamp = "X-DreamTrans-Timestamp"
+	HeaderSignature = "X-DreamTrans-Signature"
+)
+
+// maxBackoff caps the wait between retries
+const maxBackoff = time.Minute
+
+// Delivery results
+const (
+	ResultSuccess    = "success"
+	ResultRetry      = "retry"
+	ResultDeadLetter = "dead_letter"
+)
+
+// delivery is an event queued for one sink
+type delivery struct {
+	ID        string
+	Sink      string
+	EventID   string
+	EventType string
+	Body      []byte
+}
+
+// Delivery is one attempt in the delivery log
+type Delivery struct {
+	ID         string    `json:"id"`
+	Sink       string    `json:"sink"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	Result     string    `json:"result"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Time       time.Time `json:"time"`
+}
+
+// Sign returns the signature of a delivery body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// errPermanent marks responses that are not retried
+var errPermanent = errors.New("permanent failure")
+
+// deliver posts an event to a sink, retrying with exponential backoff.
+// Events that still fail, or that the sink rejects, are moved to the dead
+// letter queue.
+func (d *Dispatcher) deliver(ctx context.Context, s *sink, dl *delivery) {
+	backoff := d.cfg.RetryBackoff
+	var err error
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		start := time.Now()
+		var status int
+		status, err = d.post(ctx, s, dl)
+		entry := Delivery{
+			ID:         dl.ID,
+			Sink:       s.Name,
+			EventID:    dl.EventID,
+			EventType:  dl.EventType,
+			Attempt:    attempt,
+			Result:     ResultSuccess,
+			StatusCode: status,
+			DurationMS: time.Since(start).Milliseconds(),
+			Time:       start.UTC(),
+		}
+		if err == nil {
+			d.log.add(entry)
+			metrics.WebhookDeliveries.WithLabelValues(s.Name, ResultSuccess).Inc()
+			return
+		}
+		entry.Error = err.Error()
+		if errors.Is(err, errPermanent) || attempt == d.cfg.MaxAttempts {
+			entry.Result = ResultDeadLetter
+			d.log.add(entry)
+			break
+		}
+		entry.Result = ResultRetry
+		d.log.add(entry)
+		metrics.WebhookDeliveries.WithLabelValues(s.Name, ResultRetry).Inc()
+		d.logger.WarnContext(ctx, "Webhook delivery failed, retrying", "sink", s.Name, "event_type", dl.EventType, "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			// Keep the event for redelivery after a restart
+			err = fmt.Errorf("%w (shutting down)", err)
+			attempt = d.cfg.MaxAttempts
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues(s.Name, ResultDeadLetter).Inc()
+	d.logger.ErrorContext(ctx, "Webhook delivery failed, moved to dead letters", "sink", s.Name, "event_type", dl.EventType, "delivery_id", dl.ID, "error", err)
+	if err := d.dead.add(dl, err); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to store dead letter", "delivery_id", dl.ID, "error", err)
+	}
+}
+
+// post sends one delivery attempt and returns the response status. Client
+// errors other than 408 and 429 are permanent.
+func (d *Dispatcher) post(ctx context.Context, s *sink, dl *delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(dl.Body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, dl.EventType)
+	req.Header.Set(HeaderDelivery, dl.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if s.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, dl.Body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return resp.StatusCode, nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return resp.StatusCode, fmt.Errorf("%w: webhook returned %s", errPermanent, resp.Status)
+	default:
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+// deliveryLog keeps the most recent delivery attempts
+type deliveryLog struct {
+	mu      sync.Mutex
+	entries []Delivery
+	next    int
+	full    bool
+}
+
+func newDeliveryLog(size int) *deliveryLog {
+	return &deliveryLog{entries: make([]Delivery, size)}
+}
+
+func (l *deliveryLog) add(d Delivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = d
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Deliveries returns the logged delivery attempts, most recent first,
+// optionally filtered by sink and event type
+func (d *Dispatcher) Deliveries(sinkName, eventType string) []Delivery {
+	l := d.log
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	out := []Delivery{}
+	for i := 1; i <= n; i++ {
+		e := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if (sinkName == "" || e.Sink == sinkName) && (eventType == "" || e.EventType == eventType) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,211 @@
+// Package webhooks delivers transcript lifecycle events to external systems
+// as signed JSON POSTs, with retries, a dead letter queue and a delivery log
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/alerts"
+	"github.com/dreamtrans/backend/internal/config"
+	"github.com/dreamtrans/backend/internal/logging"
+	"github.com/dreamtrans/backend/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Event types
+const (
+	EventSessionStarted = "session.started"
+	EventSegmentFinal   = "segment.final"
+	EventTranslation    = "translation"
+	EventSessionEnded   = "session.ended"
+	EventBatchJobDone   = "batch.job.done"
+	EventAlert          = "alert"
+)
+
+// Event is the body of a delivery
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Time      time.Time   `json:"time"`
+	SessionID string      `json:"session_id,omitempty"`
+	Data      interface{} `json:"data"`
+}
+
+// Session is the data of session.started and session.ended events
+type Session struct {
+	Source   string   `json:"source"`
+	Title    string   `json:"title,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	LiveCode string   `json:"live_code,omitempty"`
+	// Segments is the number of final segments on session.ended
+	Segments int `json:"segments,omitempty"`
+}
+
+// Segment is the data of segment.final and translation events
+type Segment struct {
+	Language     string  `json:"language,omitempty"`
+	Speaker      string  `json:"speaker,omitempty"`
+	SpeakerLabel string  `json:"speaker_label,omitempty"`
+	Text         string  `json:"text"`
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+}
+
+// BatchJob is the data of batch.job.done events
+type BatchJob struct {
+	JobID    string  `json:"job_id"`
+	DataName string  `json:"data_name,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration"`
+	// Translations lists the languages the transcript was translated into
+	Translations []string `json:"translations,omitempty"`
+	Summarized   bool     `json:"summarized"`
+}
+
+// sink is a configured endpoint with its queue
+type sink struct {
+	config.WebhookSink
+	queue chan *delivery
+}
+
+// accepts reports whether the sink's event filter selects the event type.
+// Filters are exact types, "*" or a prefix such as "session.*".
+func (s *sink) accepts(typ string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, f := range s.Events {
+		if f == "*" || f == typ || (strings.HasSuffix(f, ".*") && strings.HasPrefix(typ, strings.TrimSuffix(f, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher queues events for the sinks that accept them and delivers them
+// in the background. Each sink has its own queue and delivers in order, so a
+// slow or failing sink does not hold up the others.
+type Dispatcher struct {
+	cfg     config.WebhooksConfig
+	sinks   []*sink
+	client  *http.Client
+	dead    *deadLetters
+	log     *deliveryLog
+	logger  *slog.Logger
+	running sync.WaitGroup
+}
+
+// New creates a dispatcher for the configured sinks. It returns nil when
+// there are none.
+func New(cfg config.WebhooksConfig) (*Dispatcher, error) {
+	if len(cfg.Sinks) == 0 {
+		return nil, nil
+	}
+	dead, err := newDeadLetters(cfg.DeadLetterDir)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		dead:   dead,
+		log:    newDeliveryLog(cfg.LogSize),
+		logger: logging.For("webhooks"),
+	}
+	for _, s := range cfg.Sinks {
+		d.sinks = append(d.sinks, &sink{WebhookSink: s, queue: make(chan *delivery, cfg.QueueSize)})
+	}
+	return d, nil
+}
+
+// Emit queues an event for every sink that accepts its type. Events are
+// dropped for sinks whose queue is full. A nil Dispatcher drops every event.
+func (d *Dispatcher) Emit(ctx context.Context, typ, sessionID string, data interface{}) {
+	if d == nil {
+		return
+	}
+	ev := Event{ID: logging.NewID(), Type: typ, Time: time.Now().UTC(), SessionID: sessionID, Data: data}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to encode event", "event_type", typ, "error", err)
+		return
+	}
+	for _, s := range d.sinks {
+		if !s.accepts(typ) {
+			continue
+		}
+		dl := &delivery{ID: logging.NewID(), Sink: s.Name, EventID: ev.ID, EventType: typ, Body: body}
+		select {
+		case s.queue <- dl:
+		default:
+			metrics.WebhookDeliveries.WithLabelValues(s.Name, "dropped").Inc()
+			d.logger.WarnContext(ctx, "Webhook queue full, dropping event", "sink", s.Name, "event_type", typ)
+		}
+	}
+}
+
+// Notify emits an alert event, so that the dispatcher can be used as an
+// alerts.Notifier
+func (d *Dispatcher) Notify(ctx context.Context, a alerts.Alert) {
+	d.Emit(ctx, EventAlert, a.SessionID, a)
+}
+
+// Run delivers queued events until ctx is done. Events still queued then
+// are moved to the dead letter queue, so they can be redelivered after a
+// restart.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	for _, s := range d.sinks {
+		d.running.Add(1)
+		go func(s *sink) {
+			defer d.running.Done()
+			for ctx.Err() == nil {
+				select {
+				case dl := <-s.queue:
+					d.deliver(ctx, s, dl)
+				case <-ctx.Done():
+				}
+			}
+			d.shelve(s)
+		}(s)
+	}
+	d.running.Wait()
+}
+
+// sink returns the sink named name
+func (d *Dispatcher) sink(name string) (*sink, error) {
+	for _, s := range d.sinks {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown webhook sink %q", name)
+}
+
+// shelve moves the events queued for a sink to the dead letter queue
+func (d *Dispatcher) shelve(s *sink) {
+	for {
+		select {
+		case dl := <-s.queue:
+			if err := d.dead.add(dl, errors.New("not delivered before shutdown")); err != nil {
+				d.logger.Error("Failed to store dead letter", "delivery_id", dl.ID, "error", err)
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dreamtrans/backend/internal/config"
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently with Python's hmac module
+	const want = "sha256=5056f09710e0bebdbcd623bb1a7714db4eac94f18745b31b96dd55a69f444e14"
+	if got := Sign("whsec_test", "1700000000", []byte(`{"id":"evt-1"}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestSinkAccepts(t *testing.T) {
+	tests := []struct {
+		events []string
+		typ    string
+		want   bool
+	}{
+		{nil, EventSegmentFinal, true},
+		{[]string{"*"}, EventAlert, true},
+		{[]string{EventSegmentFinal}, EventSegmentFinal, true},
+		{[]string{EventSegmentFinal}, EventTranslation, false},
+		{[]string{"session.*"}, EventSessionStarted, true},
+		{[]string{"session.*"}, EventSessionEnded, true},
+		{[]string{"session.*"}, EventSegmentFinal, false},
+		{[]string{"session*"}, EventSessionStarted, false},
+		{[]string{"batch.*", EventAlert}, EventAlert, true},
+	}
+	for _, tt := range tests {
+		s := &sink{WebhookSink: config.WebhookSink{Events: tt.events}}
+		if got := s.accepts(tt.typ); got != tt.want {
+			t.Errorf("events %q accepts %s = %v, want %v", tt.events, tt.typ, got, tt.want)
+		}
+	}
+}
+
+// receiver is a webhook endpoint answering with the given status codes in
+// turn and recording the requests it received
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	status := http.StatusOK
+	if n := len(rc.requests); n < len(rc.statuses) {
+		status = rc.statuses[n]
+	}
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func newDispatcher(t *testing.T, url string, events ...string) *Dispatcher {
+	t.Helper()
+	cfg := config.Default().Webhooks
+	cfg.Sinks = []config.WebhookSink{{Name: "test", URL: url, Secret: "whsec_test", Events: events}}
+	cfg.MaxAttempts = 3
+	cfg.RetryBackoff = time.Millisecond
+	cfg.DeadLetterDir = t.TempDir()
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return d
+}
+
+// drain delivers the queued events of every sink
+func drain(d *Dispatcher) {
+	for _, s := range d.sinks {
+		for {
+			select {
+			case dl := <-s.queue:
+				d.deliver(context.Background(), s, dl)
+				continue
+			default:
+			}
+			break
+		}
+	}
+}
+
+func TestDeliverySignedAndRetried(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d := newDispatcher(t, srv.URL)
+
+	d.Emit(context.Background(), EventSegmentFinal, "s1", Segment{Text: "hello"})
+	drain(d)
+
+	if n := rc.count(); n != 3 {
+		t.Fatalf("received %d attempts, want 3", n)
+	}
+	req, body := rc.requests[2], rc.bodies[2]
+	if got, want := req.Header.Get(HeaderSignature), Sign("whsec_test", req.Header.Get(HeaderTimestamp), body); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if req.Header.Get(HeaderEvent) != EventSegmentFinal || req.Header.Get(HeaderDelivery) == "" {
+		t.Errorf("headers %v", req.Header)
+	}
+	var ev Event
+	if err := json.Unmarshal(body, &ev); err != nil || ev.Type != EventSegmentFinal || ev.SessionID != "s1" {
+		t.Errorf("body %s: %v", body, err)
+	}
+
+	log := d.Deliveries("", "")
+	if len(log) != 3 || log[0].Result != ResultSuccess || log[1].Result != ResultRetry || log[2].Result != ResultRetry {
+		t.Errorf("delivery log %+v, want success after two retries", log)
+	}
+	if letters, _ := d.DeadLetters(""); len(letters) != 0 {
+		t.Errorf("delivered event left %d dead letters", len(letters))
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"client error is permanent", []int{http.StatusBadRequest}, 1},
+		{"unauthorized is permanent", []int{http.StatusUnauthorized}, 1},
+		{"server errors until max attempts", []int{500, 502, 503}, 3},
+		{"request timeouts until max attempts", []int{408, 408, 408}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+			d := newDispatcher(t, srv.URL)
+
+			d.Emit(context.Background(), EventSessionEnded, "s1", Session{Source: "live"})
+			drain(d)
+			if n := rc.count(); n != tt.attempts {
+				t.Errorf("received %d attempts, want %d", n, tt.attempts)
+			}
+			letters, err := d.DeadLetters("test")
+			if err != nil || len(letters) != 1 {
+				t.Fatalf("dead letters = %+v, %v, want one", letters, err)
+			}
+			if letters[0].EventType != EventSessionEnded || string(letters[0].Event) != string(rc.bodies[0]) {
+				t.Errorf("dead letter %+v does not keep the event", letters[0])
+			}
+		})
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusNotFound}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d := newDispatcher(t, srv.URL)
+
+	d.Emit(context.Background(), EventBatchJobDone, "", BatchJob{JobID: "job-1"})
+	drain(d)
+	letters, err := d.DeadLetters("")
+	if err != nil || len(letters) != 1 {
+		t.Fatalf("dead letters = %+v, %v, want one", letters, err)
+	}
+
+	if err := d.Redeliver(letters[0].ID); err != nil {
+		t.Fatalf("Redeliver error: %v", err)
+	}
+	if err := d.Redeliver(letters[0].ID); err != ErrNotFound {
+		t.Errorf("second Redeliver error = %v, want ErrNotFound", err)
+	}
+	drain(d)
+	if n := rc.count(); n != 2 {
+		t.Fatalf("received %d attempts, want 2", n)
+	}
+	if string(rc.bodies[1]) != string(rc.bodies[0]) || rc.requests[1].Header.Get(HeaderDelivery) != letters[0].ID {
+		t.Error("redelivery differs from the original delivery")
+	}
+	if letters, _ := d.DeadLetters(""); len(letters) != 0 {
+		t.Errorf("redelivered event left %d dead letters", len(letters))
+	}
+}
+
+func TestEmitFilters(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d := newDispatcher(t, srv.URL, "session.*")
+
+	d.Emit(context.Background(), EventSessionStarted, "s1", Session{})
+	d.Emit(context.Background(), EventSegmentFinal, "s1", Segment{})
+	d.Emit(context.Background(), EventSessionEnded, "s1", Session{})
+	drain(d)
+	if n := rc.count(); n != 2 {
+		t.Fatalf("received %d events, want the 2 session events", n)
+	}
+	for _, r := range rc.requests {
+		if r.Header.Get(HeaderEvent) == EventSegmentFinal {
+			t.Error("filtered event delivered")
+		}
+	}
+}
+
+func TestShutdownShelvesQueuedEvents(t *testing.T) {
+	d := newDispatcher(t, "http://127.0.0.1:1")
+	d.Emit(context.Background(), EventSessionStarted, "s1", Session{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+	if letters, err := d.DeadLetters(""); err != nil || len(letters) != 1 {
+		t.Errorf("dead letters after shutdown = %+v, %v, want the queued event", letters, err)
+	}
+}